@@ -0,0 +1,120 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ApplyClassDefaults fills the fields the ModelServe leaves unset from the
+// class. Node selector keys set on the ModelServe win over those of the class.
+// The controller applies them on every reconcile rather than on admission, so
+// the spec keeps only what the user set and class edits take effect.
+func (r *ModelServe) ApplyClassDefaults(class *ModelServeClass) {
+	if r.Spec.Storage == nil && class.Spec.Storage != nil {
+		r.Spec.Storage = class.Spec.Storage.DeepCopy()
+	}
+
+	if len(class.Spec.NodeSelector) > 0 {
+		selector := make(map[string]string, len(class.Spec.NodeSelector)+len(r.Spec.NodeSelector))
+		for k, v := range class.Spec.NodeSelector {
+			selector[k] = v
+		}
+		for k, v := range r.Spec.NodeSelector {
+			selector[k] = v
+		}
+		r.Spec.NodeSelector = selector
+	}
+}
+
+// lookupClass returns the ModelServeClass named by spec.className, or nil when
+// none is set or the cluster cannot be read
+func (r *ModelServe) lookupClass(ctx context.Context) (*ModelServeClass, error) {
+	if r.Spec.ClassName == "" || webhookClient == nil {
+		return nil, nil
+	}
+
+	class := &ModelServeClass{}
+	if err := webhookClient.Get(ctx, types.NamespacedName{Name: r.Spec.ClassName}, class); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, fmt.Errorf("className: ModelServeClass %s not found", r.Spec.ClassName)
+		}
+		return nil, fmt.Errorf("failed to look up ModelServeClass %s: %v", r.Spec.ClassName, err)
+	}
+	return class, nil
+}
+
+// validateClass checks the ModelServe against the image and resource limits
+// of its ModelServeClass
+func (r *ModelServe) validateClass(ctx context.Context) error {
+	class, err := r.lookupClass(ctx)
+	if err != nil || class == nil {
+		return err
+	}
+	return r.ValidateClassPolicy(class)
+}
+
+// ValidateClassPolicy checks the server image is allowed and the model and its
+// replica groups stay within the resource caps of the class
+func (r *ModelServe) ValidateClassPolicy(class *ModelServeClass) error {
+	if len(class.Spec.AllowedImages) > 0 && !imageAllowed(r.Spec.Image, class.Spec.AllowedImages) {
+		return fmt.Errorf("image %s is not allowed by ModelServeClass %s", r.Spec.Image, class.Name)
+	}
+
+	check := func(field string, memory, cpu, gpu int32) error {
+		if class.Spec.MaxMemoryLimit > 0 && memory > class.Spec.MaxMemoryLimit {
+			return fmt.Errorf("%smemoryLimit %d exceeds %d of ModelServeClass %s", field, memory, class.Spec.MaxMemoryLimit, class.Name)
+		}
+		if class.Spec.MaxCPULimit > 0 && cpu > class.Spec.MaxCPULimit {
+			return fmt.Errorf("%scpuLimit %d exceeds %d of ModelServeClass %s", field, cpu, class.Spec.MaxCPULimit, class.Name)
+		}
+		if class.Spec.MaxGPUCount > 0 && gpu > class.Spec.MaxGPUCount {
+			return fmt.Errorf("%sgpuCount %d exceeds %d of ModelServeClass %s", field, gpu, class.Spec.MaxGPUCount, class.Name)
+		}
+		return nil
+	}
+
+	if err := check("", r.Spec.MemoryLimit, r.Spec.CPULimit, r.GPUs()); err != nil {
+		return err
+	}
+	for _, g := range r.Spec.ReplicaGroups {
+		if err := check("replicaGroups: "+g.Name+" ", g.MemoryLimit, g.CPULimit, g.GPUCount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// imageAllowed reports whether the image matches an entry of allowed. An entry
+// ending in * matches by prefix.
+func imageAllowed(image string, allowed []string) bool {
+	for _, pattern := range allowed {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(image, prefix) {
+				return true
+			}
+		} else if image == pattern {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"os"
+	"strings"
+	"text/template"
+)
+
+// CustomDownloadCommandEnv must be "true" in the operator environment before
+// download.command replaces the built-in download. The command runs arbitrary
+// shell with the MinIO credentials, so clusters opt in explicitly.
+const CustomDownloadCommandEnv = "ALLOW_CUSTOM_DOWNLOAD_COMMAND"
+
+// CustomDownloadCommandAllowed reports whether the cluster enabled download.command
+func CustomDownloadCommandAllowed() bool {
+	return os.Getenv(CustomDownloadCommandEnv) == "true"
+}
+
+// DownloadCommandVars are the values download.command may reference, e.g.
+// {{.Endpoint}} or {{.Dest}}
+type DownloadCommandVars struct {
+	// ModelName is spec.modelName
+	ModelName string
+	// Endpoint, Bucket and Path locate the model in MinIO
+	Endpoint string
+	Bucket   string
+	Path     string
+	// Dest is the file under /models the command must write the model to
+	Dest string
+}
+
+// RenderDownloadCommand substitutes vars into a download.command template.
+// Unknown variables are an error.
+func RenderDownloadCommand(command string, vars DownloadCommandVars) (string, error) {
+	tmpl, err := template.New("download").Option("missingkey=error").Parse(command)
+	if err != nil {
+		return "", err
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, vars); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
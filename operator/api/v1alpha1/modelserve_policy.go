@@ -0,0 +1,187 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// PoliciesConfigMap holds the organization policies every ModelServe must
+// satisfy. It lives in the operator namespace and holds one JSON encoded
+// PolicyRule per key, so platform teams can change them without a rebuild.
+const PoliciesConfigMap = "modelserve-policies"
+
+// PolicyRule constrains one field of the ModelServe, addressed by a JSON
+// pointer into the object (e.g. /metadata/labels/cost-center or
+// /spec/storage/storageClassName). Enum and Pattern only apply to fields
+// that are set.
+type PolicyRule struct {
+	// Path is the JSON pointer of the field
+	Path string `json:"path"`
+
+	// Required rejects objects without the field
+	Required bool `json:"required,omitempty"`
+
+	// Enum lists the allowed values of the field
+	Enum []string `json:"enum,omitempty"`
+
+	// Pattern is a regular expression the value of the field must match
+	Pattern string `json:"pattern,omitempty"`
+
+	// Message replaces the generated violation message
+	Message string `json:"message,omitempty"`
+}
+
+// loadPolicies reads the policy rules by ConfigMap key. A missing ConfigMap
+// enforces no policies; an unreadable ConfigMap or an invalid rule rejects
+// the ModelServe so that a broken policy never lets objects through.
+func loadPolicies(ctx context.Context) (map[string]PolicyRule, error) {
+	if webhookClient == nil {
+		return nil, nil
+	}
+
+	namespace := os.Getenv("OPERATOR_NAMESPACE")
+	if namespace == "" {
+		namespace = "default"
+	}
+
+	cm := &corev1.ConfigMap{}
+	if err := webhookClient.Get(ctx, types.NamespacedName{Name: PoliciesConfigMap, Namespace: namespace}, cm); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read ModelServe policies: %v", err)
+	}
+
+	policies := make(map[string]PolicyRule, len(cm.Data))
+	for key, raw := range cm.Data {
+		var rule PolicyRule
+		if err := json.Unmarshal([]byte(raw), &rule); err != nil {
+			return nil, fmt.Errorf("invalid ModelServe policy %s: %v", key, err)
+		}
+		if !strings.HasPrefix(rule.Path, "/") {
+			return nil, fmt.Errorf("invalid ModelServe policy %s: path must be a JSON pointer starting with /", key)
+		}
+		if rule.Pattern != "" {
+			if _, err := regexp.Compile(rule.Pattern); err != nil {
+				return nil, fmt.Errorf("invalid ModelServe policy %s: %v", key, err)
+			}
+		}
+		policies[key] = rule
+	}
+	return policies, nil
+}
+
+// validatePolicies evaluates the policies of the ConfigMap against the
+// ModelServe and reports every violation at once
+func (r *ModelServe) validatePolicies(ctx context.Context) error {
+	policies, err := loadPolicies(ctx)
+	if err != nil || len(policies) == 0 {
+		return err
+	}
+
+	raw, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	var doc interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(policies))
+	for key := range policies {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var violations []string
+	for _, key := range keys {
+		if msg := policies[key].violation(doc); msg != "" {
+			violations = append(violations, fmt.Sprintf("%s: %s", key, msg))
+		}
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("policy violations: %s", strings.Join(violations, "; "))
+	}
+	return nil
+}
+
+// violation describes how the document breaks the rule, or is empty
+func (p PolicyRule) violation(doc interface{}) string {
+	value, found := lookupPointer(doc, p.Path)
+	if !found {
+		if p.Required {
+			return p.message(fmt.Sprintf("%s is required", p.Path))
+		}
+		return ""
+	}
+
+	s := fmt.Sprint(value)
+	if len(p.Enum) > 0 {
+		allowed := false
+		for _, v := range p.Enum {
+			if v == s {
+				allowed = true
+			}
+		}
+		if !allowed {
+			return p.message(fmt.Sprintf("%s must be one of %s, got %q", p.Path, strings.Join(p.Enum, ", "), s))
+		}
+	}
+	if p.Pattern != "" && !regexp.MustCompile(p.Pattern).MatchString(s) {
+		return p.message(fmt.Sprintf("%s must match %s, got %q", p.Path, p.Pattern, s))
+	}
+	return ""
+}
+
+func (p PolicyRule) message(generated string) string {
+	if p.Message != "" {
+		return p.Message
+	}
+	return generated
+}
+
+// lookupPointer resolves a JSON pointer (RFC 6901) in the decoded document.
+// Empty strings count as unset, as omitempty fields do.
+func lookupPointer(doc interface{}, pointer string) (interface{}, bool) {
+	current := doc
+	for _, token := range strings.Split(pointer, "/")[1:] {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = obj[token]; !ok || current == nil {
+			return nil, false
+		}
+	}
+	if s, ok := current.(string); ok && s == "" {
+		return nil, false
+	}
+	return current, true
+}
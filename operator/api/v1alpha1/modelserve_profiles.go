@@ -0,0 +1,101 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ProfilesConfigMap overrides the built-in resource profiles. It lives in the
+// operator namespace and holds one JSON encoded ResourceProfile per key.
+const ProfilesConfigMap = "modelserve-profiles"
+
+// ResourceProfile holds the defaults applied by spec.profile
+type ResourceProfile struct {
+	MemoryLimit int32 `json:"memoryLimit,omitempty"`
+	CPULimit    int32 `json:"cpuLimit,omitempty"`
+	GPUCount    int32 `json:"gpuCount,omitempty"`
+	ContextSize int32 `json:"contextSize,omitempty"`
+}
+
+// defaultProfiles are used when the ConfigMap does not define a profile
+var defaultProfiles = map[string]ResourceProfile{
+	"small":  {MemoryLimit: 2048, CPULimit: 1000, ContextSize: 2048},
+	"medium": {MemoryLimit: 8192, CPULimit: 4000, ContextSize: 4096},
+	"large":  {MemoryLimit: 16384, CPULimit: 8000, GPUCount: 1, ContextSize: 8192},
+}
+
+// lookupProfile returns the named profile, merging the ConfigMap entry over
+// the built-in values. An unreadable ConfigMap falls back to the built-ins.
+func lookupProfile(ctx context.Context, name string) ResourceProfile {
+	profile := defaultProfiles[name]
+	if webhookClient == nil {
+		return profile
+	}
+
+	namespace := os.Getenv("OPERATOR_NAMESPACE")
+	if namespace == "" {
+		namespace = "default"
+	}
+
+	cm := &corev1.ConfigMap{}
+	if err := webhookClient.Get(ctx, types.NamespacedName{Name: ProfilesConfigMap, Namespace: namespace}, cm); err != nil {
+		if !errors.IsNotFound(err) {
+			modelservelog.Error(err, "failed to read resource profiles, using built-in values")
+		}
+		return profile
+	}
+
+	if raw, ok := cm.Data[name]; ok {
+		if err := json.Unmarshal([]byte(raw), &profile); err != nil {
+			modelservelog.Error(err, "invalid resource profile, using built-in values", "profile", name)
+			return defaultProfiles[name]
+		}
+	}
+	return profile
+}
+
+// applyProfile fills the resource fields left unset from spec.profile. The
+// memory and CPU limits have no meaning at 0, the GPU count and context size
+// are pointers so an explicit 0 is kept.
+func (r *ModelServe) applyProfile(ctx context.Context) {
+	if r.Spec.Profile == "" {
+		return
+	}
+
+	profile := lookupProfile(ctx, r.Spec.Profile)
+	if r.Spec.MemoryLimit == 0 {
+		r.Spec.MemoryLimit = profile.MemoryLimit
+	}
+	if r.Spec.CPULimit == 0 {
+		r.Spec.CPULimit = profile.CPULimit
+	}
+	if r.Spec.GPUCount == nil && profile.GPUCount != 0 {
+		gpuCount := profile.GPUCount
+		r.Spec.GPUCount = &gpuCount
+	}
+	if r.Spec.ContextSize == nil && profile.ContextSize != 0 {
+		contextSize := profile.ContextSize
+		r.Spec.ContextSize = &contextSize
+	}
+}
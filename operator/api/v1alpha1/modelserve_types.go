@@ -17,6 +17,10 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -31,8 +35,12 @@ type ModelServeSpec struct {
 	// ModelUUID is the unique identifier for the model in the database
 	ModelUUID string `json:"modelUuid"`
 
-	// MinIOPath is the path to the model in MinIO (e.g., "models/Qwen.gguf")
-	MinIOPath string `json:"minioPath"`
+	// MinIOPath is the path to the model in MinIO (e.g., "models/Qwen.gguf").
+	// When unset the defaulting webhook derives it from modelName and
+	// quantization, and derives it again when either changes. Without the
+	// webhook the controller derives it on every reconcile.
+	// +optional
+	MinIOPath string `json:"minioPath,omitempty"`
 
 	// MinIOEndpoint is the MinIO service endpoint (e.g., "minio:9000")
 	// +optional
@@ -46,10 +54,19 @@ type ModelServeSpec struct {
 	// +optional
 	Image string `json:"image,omitempty"`
 
-	// Replicas is the number of replicas to run (optional, default 1)
+	// Replicas is the number of replicas to run (optional, default 1). It is
+	// exposed through the scale subresource for autoscalers.
 	// +optional
 	Replicas *int32 `json:"replicas,omitempty"`
 
+	// WarmPool is the minimum number of replicas kept running even when an
+	// autoscaler or idle scaler lowers replicas below it, down to zero. It
+	// acts as the HPA minReplicas of the model, trading cost for no cold
+	// starts. A pod retained by retainOnFailure still scales the model to zero.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	WarmPool int32 `json:"warmPool,omitempty"`
+
 	// RuntimeParams are additional runtime parameters for llama.cpp
 	// +optional
 	RuntimeParams string `json:"runtimeParams,omitempty"`
@@ -61,6 +78,459 @@ type ModelServeSpec struct {
 	// CPULimit is the maximum CPU in millicores for the container
 	// +optional
 	CPULimit int32 `json:"cpuLimit,omitempty"`
+
+	// GPUCount is the number of nvidia.com/gpu devices for the container. An
+	// explicit 0 wins over the GPUs of spec.profile.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	GPUCount *int32 `json:"gpuCount,omitempty"`
+
+	// GPUResourceName is the extended resource requested for gpuCount, e.g.
+	// nvidia.com/mig-1g.5gb for a MIG slice or nvidia.com/gpu.shared for a
+	// time-sliced GPU. Defaults to nvidia.com/gpu.
+	// +optional
+	GPUResourceName string `json:"gpuResourceName,omitempty"`
+
+	// ContextSize is the prompt context size passed to the server as --ctx-size.
+	// An explicit 0 keeps the server default over that of spec.profile.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	ContextSize *int32 `json:"contextSize,omitempty"`
+
+	// ParallelSlots is the number of requests the server processes
+	// concurrently, passed as --parallel. It replaces --parallel or -np in
+	// runtimeParams.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ParallelSlots *int32 `json:"parallelSlots,omitempty"`
+
+	// ProgressDeadlineSeconds is how long a rollout may make no progress before
+	// the ModelServe is marked Failed. Defaults to 600 to allow for model loads.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ProgressDeadlineSeconds *int32 `json:"progressDeadlineSeconds,omitempty"`
+
+	// MinReadySeconds is how long a new pod must stay ready before it counts
+	// as available, so a flapping server is not marked Running. Defaults to 5.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinReadySeconds *int32 `json:"minReadySeconds,omitempty"`
+
+	// AutomountServiceAccountToken mounts the API token into the model pods.
+	// Defaults to false; model servers do not talk to the API server. The
+	// lazy download activator always mounts its own token.
+	// +optional
+	AutomountServiceAccountToken *bool `json:"automountServiceAccountToken,omitempty"`
+
+	// WarmupBackend routes requests to a shared backend answering 503 with a
+	// Retry-After header while no model pod is available, instead of failing
+	// with connection errors during downloads and cold starts
+	// +optional
+	WarmupBackend bool `json:"warmupBackend,omitempty"`
+
+	// RetainOnFailure keeps the first crash looping model pod for debugging.
+	// The pod is detached from the Deployment, which is scaled to zero, so its
+	// logs and model volume stay intact. Delete the retained pod to resume;
+	// deleting the ModelServe removes it too.
+	// +optional
+	RetainOnFailure bool `json:"retainOnFailure,omitempty"`
+
+	// ModelReadOnly mounts the model volume read-only into the server
+	// container. Init containers keep write access to download the model.
+	// Defaults to true.
+	// +optional
+	ModelReadOnly *bool `json:"modelReadOnly,omitempty"`
+
+	// ReadOnlyRootFilesystem runs the server container with a read-only root
+	// filesystem. /tmp and the llama.cpp cache directory are mounted from
+	// emptyDir volumes so the server keeps its scratch space.
+	// +optional
+	ReadOnlyRootFilesystem bool `json:"readOnlyRootFilesystem,omitempty"`
+
+	// ExtraPorts are additional server container ports, e.g. for metrics,
+	// admin or gRPC endpoints, which are mirrored onto the Service. Each port
+	// needs a unique name and may not use the server port 8080 or 9090.
+	// +optional
+	ExtraPorts []corev1.ContainerPort `json:"extraPorts,omitempty"`
+
+	// GPUSharing co-schedules small models onto the same GPU node
+	// +optional
+	GPUSharing *GPUSharingSpec `json:"gpuSharing,omitempty"`
+
+	// Download configures how the model file is fetched and verified
+	// +optional
+	Download *DownloadSpec `json:"download,omitempty"`
+
+	// Quantization selects a variant of the model stored as
+	// models/<base>.<quantization>.gguf, where base is modelName without its
+	// .gguf extension. It names the local model file and the default minioPath;
+	// an explicit minioPath still wins for the download.
+	// +kubebuilder:validation:Enum=Q2_K;Q3_K_S;Q3_K_M;Q3_K_L;Q4_0;Q4_K_S;Q4_K_M;Q5_0;Q5_K_S;Q5_K_M;Q6_K;Q8_0;F16;F32
+	// +optional
+	Quantization string `json:"quantization,omitempty"`
+
+	// ClassName selects the ModelServeClass whose policies apply to the model.
+	// Fields set on the ModelServe take precedence over the class defaults.
+	// +optional
+	ClassName string `json:"className,omitempty"`
+
+	// Profile fills in memory, CPU, GPU and context size defaults from a named
+	// resource profile. Fields set explicitly take precedence.
+	// +kubebuilder:validation:Enum=small;medium;large
+	// +optional
+	Profile string `json:"profile,omitempty"`
+
+	// SecurityProfile controls the security context of the generated pods.
+	// Restricted, the default, runs every container as non-root with the
+	// settings required by the restricted Pod Security Standard. None leaves
+	// the security context to the images.
+	// +kubebuilder:validation:Enum=Restricted;None
+	// +optional
+	SecurityProfile string `json:"securityProfile,omitempty"`
+
+	// FSGroup owns the mounted volumes, so the non-root containers can write
+	// the model directory. Defaults to the non-root UID under the Restricted
+	// security profile.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	FSGroup *int64 `json:"fsGroup,omitempty"`
+
+	// Backends turns the route of this model into a router fanning requests
+	// out to other ModelServes of the namespace by weight. List the model
+	// itself to keep serving a share of the traffic.
+	// +optional
+	Backends []ModelServeRef `json:"backends,omitempty"`
+
+	// ReplicaGroups run additional replicas with their own resources and
+	// server arguments, e.g. one large context replica next to small ones.
+	// Every group gets its own Deployment behind the model Service; spec.replicas
+	// sizes the base Deployment.
+	// +optional
+	ReplicaGroups []ReplicaGroup `json:"replicaGroups,omitempty"`
+
+	// ConfigFile mounts a ConfigMap as a file into the server container.
+	// Pods are rolled whenever the ConfigMap content changes.
+	// +optional
+	ConfigFile *ConfigFileSpec `json:"configFile,omitempty"`
+
+	// NetworkPolicy restricts the traffic allowed to and from the model pods
+	// +optional
+	NetworkPolicy *NetworkPolicySpec `json:"networkPolicy,omitempty"`
+
+	// PodLabels are additional labels set on the model pods. Labels used by
+	// the Service selector cannot be overridden.
+	// +optional
+	PodLabels map[string]string `json:"podLabels,omitempty"`
+
+	// NodeSelector constrains the nodes the model pods run on. It is merged
+	// over the node selector of the ModelServeClass.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// DeploymentAnnotations are additional annotations set on the Deployment
+	// itself, not its pod template. Annotations managed by the operator or
+	// the Deployment controller cannot be overridden. Keys removed here are
+	// removed from the Deployment as well.
+	// +optional
+	DeploymentAnnotations map[string]string `json:"deploymentAnnotations,omitempty"`
+
+	// StartupScript is a shell script run in an init container after the model
+	// is downloaded and before the server starts. It shares the model volume at /models.
+	// The server starts once the script exits successfully, so the script must
+	// not start llama-server or leave anything running.
+	// +optional
+	StartupScript string `json:"startupScript,omitempty"`
+
+	// Monitoring configures the monitor sidecar
+	// +optional
+	Monitoring *MonitoringSpec `json:"monitoring,omitempty"`
+
+	// Storage configures where the model file is stored
+	// +optional
+	Storage *StorageSpec `json:"storage,omitempty"`
+
+	// EvictionPolicy controls how the operator reacts to nodes being terminated.
+	// PreScale starts a replacement pod as soon as a node hosting a model pod is
+	// tainted for termination, instead of waiting for the eviction.
+	// +kubebuilder:validation:Enum=None;PreScale
+	// +optional
+	EvictionPolicy string `json:"evictionPolicy,omitempty"`
+
+	// ModelDownloadMode controls when the model is downloaded. Eager downloads
+	// it before the server starts. Lazy starts a lightweight activator instead
+	// and downloads the model when the first request arrives; it requires
+	// spec.storage.size so the download survives the switch to the real server.
+	// +kubebuilder:validation:Enum=Eager;Lazy
+	// +optional
+	ModelDownloadMode string `json:"modelDownloadMode,omitempty"`
+
+	// RoutePath is the gateway path prefix the model is served under.
+	// Defaults to /<name>. In a namespace labelled with a tenant it must be
+	// under /<tenant>.
+	// +kubebuilder:validation:Pattern=`^/[a-zA-Z0-9/_.-]*$`
+	// +optional
+	RoutePath string `json:"routePath,omitempty"`
+
+	// RouteWhenReady delays creating the Ingress until a model pod is
+	// available, so clients are not routed to a model that is still starting
+	// +optional
+	RouteWhenReady bool `json:"routeWhenReady,omitempty"`
+
+	// Public serves the route without the JWT auth middleware of the gateway
+	// +optional
+	Public bool `json:"public,omitempty"`
+
+	// HeadlessService creates <name>-headless without a cluster IP next to the
+	// main Service, so clients can address individual replicas by pod DNS
+	// +optional
+	HeadlessService bool `json:"headlessService,omitempty"`
+
+	// Preflight downloads and checks the model in a CPU only Job before the
+	// Deployment is created, so a bad model fails without holding a GPU
+	// +optional
+	Preflight bool `json:"preflight,omitempty"`
+
+	// HealthCheck configures additional server health endpoints
+	// +optional
+	HealthCheck *HealthCheckSpec `json:"healthCheck,omitempty"`
+
+	// CompletionProbe gates readiness on the server completing a prompt,
+	// instead of only answering /health
+	// +optional
+	CompletionProbe *CompletionProbeSpec `json:"completionProbe,omitempty"`
+}
+
+const (
+	// EvictionPolicyNone leaves replacement of evicted pods to the Deployment
+	EvictionPolicyNone = "None"
+	// EvictionPolicyPreScale surges a replacement before a terminating node evicts the pod
+	EvictionPolicyPreScale = "PreScale"
+)
+
+const (
+	// ModelDownloadModeEager downloads the model before the server starts
+	ModelDownloadModeEager = "Eager"
+	// ModelDownloadModeLazy defers the download until the first request
+	ModelDownloadModeLazy = "Lazy"
+)
+
+const (
+	// SecurityProfileRestricted satisfies the restricted Pod Security Standard
+	SecurityProfileRestricted = "Restricted"
+	// SecurityProfileNone sets no security context
+	SecurityProfileNone = "None"
+)
+
+// LastModifiedByAnnotation holds the last user to create or change the
+// ModelServe, set by the defaulting webhook: the JWT subject of an auth token
+// sent with the change, or else the requesting Kubernetes user
+const LastModifiedByAnnotation = "model.example.com/last-modified-by"
+
+// ActivationRequestedAnnotation is set on a lazily downloaded ModelServe by its
+// activator when the first request arrives
+const ActivationRequestedAnnotation = "model.example.com/activation-requested"
+
+// ActivatorServiceAccountName returns the service account of the activator
+// standing in for the named ModelServe
+func ActivatorServiceAccountName(name string) string {
+	return name + "-activator"
+}
+
+// authTokenAnnotation holds the JWT a user authenticates the ModelServe with
+const authTokenAnnotation = "model.example.com/auth-token"
+
+// ReplicaGroup is a set of replicas overriding the resources and server
+// arguments of the ModelServe. Unset fields keep the ModelServe values.
+type ReplicaGroup struct {
+	// Name of the group, used in the name of its Deployment
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// Replicas is the number of replicas of the group
+	// +kubebuilder:validation:Minimum=0
+	Replicas int32 `json:"replicas"`
+
+	// MemoryLimit is the maximum memory in MB
+	// +optional
+	MemoryLimit int32 `json:"memoryLimit,omitempty"`
+
+	// CPULimit is the maximum CPU in millicores
+	// +optional
+	CPULimit int32 `json:"cpuLimit,omitempty"`
+
+	// GPUCount is the number of GPU devices
+	// +optional
+	GPUCount int32 `json:"gpuCount,omitempty"`
+
+	// ContextSize is the prompt context size passed as --ctx-size
+	// +optional
+	ContextSize int32 `json:"contextSize,omitempty"`
+
+	// RuntimeParams replace spec.runtimeParams for the group
+	// +optional
+	RuntimeParams string `json:"runtimeParams,omitempty"`
+}
+
+// DownloadSpec configures the model download
+type DownloadSpec struct {
+	// SHA256 is the expected hex encoded checksum of the model file. Downloads
+	// not matching it fail.
+	// +kubebuilder:validation:Pattern=`^[a-f0-9]{64}$`
+	// +optional
+	SHA256 string `json:"sha256,omitempty"`
+
+	// ChecksumObject is a MinIO object in the model bucket holding the
+	// sha256 of the model, e.g. models/llama.gguf.sha256. The download is
+	// verified against it, so rotating a model does not need a spec change.
+	// Mutually exclusive with sha256.
+	// +optional
+	ChecksumObject string `json:"checksumObject,omitempty"`
+
+	// VerifyOnStart re-verifies a model kept on the persistent volume against
+	// sha256 on every pod start and downloads it again when it does not match.
+	// Requires sha256 and storage.size.
+	// +optional
+	VerifyOnStart bool `json:"verifyOnStart,omitempty"`
+
+	// Command replaces the built-in MinIO download with a custom shell
+	// script. It is a Go template over DownloadCommandVars and must write the
+	// model to {{.Dest}}. Ignored unless the operator runs with
+	// ALLOW_CUSTOM_DOWNLOAD_COMMAND=true.
+	// +optional
+	Command string `json:"command,omitempty"`
+
+	// Image runs the download. Defaults to minio/mc:latest.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// HTTPProxy is the proxy URL of plain HTTP requests of the download
+	// +optional
+	HTTPProxy string `json:"httpProxy,omitempty"`
+
+	// HTTPSProxy is the proxy URL of HTTPS requests of the download
+	// +optional
+	HTTPSProxy string `json:"httpsProxy,omitempty"`
+
+	// NoProxy is a comma separated list of hosts, domains and CIDRs the
+	// download reaches without the proxy
+	// +optional
+	NoProxy string `json:"noProxy,omitempty"`
+}
+
+// GPUSharingSpec groups models sharing a physical GPU
+type GPUSharingSpec struct {
+	// Group is a label value; models in the same group prefer the same node.
+	// Requires a shareable gpuResourceName such as nvidia.com/gpu.shared or a
+	// MIG slice.
+	Group string `json:"group"`
+}
+
+// ModelServeRef references a ModelServe of the same namespace as a weighted backend
+type ModelServeRef struct {
+	// Name of the ModelServe
+	Name string `json:"name"`
+
+	// Weight is the relative share of the requests sent to the backend
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=1
+	// +optional
+	Weight int32 `json:"weight,omitempty"`
+}
+
+// HealthCheckSpec configures the health endpoints exposed by the server image
+type HealthCheckSpec struct {
+	// LoadingEndpoint is a path on the server port reporting model load progress
+	// as a percentage, either as a bare number or as {"progress": 42}. It must
+	// answer with a non-2xx status until the model is loaded. When set it backs
+	// the startup probe and the progress is surfaced in status.loadingProgress.
+	// +optional
+	LoadingEndpoint string `json:"loadingEndpoint,omitempty"`
+}
+
+// CompletionProbeSpec configures the readiness probe running a completion
+type CompletionProbeSpec struct {
+	// Prompt is completed on every probe. Defaults to "Hello".
+	// +optional
+	Prompt string `json:"prompt,omitempty"`
+
+	// TimeoutSeconds bounds the completion, including the prompt evaluation.
+	// Defaults to 10.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TimeoutSeconds int32 `json:"timeoutSeconds,omitempty"`
+}
+
+// ConfigFileSpec references a ConfigMap mounted into the server container
+type ConfigFileSpec struct {
+	// ConfigMapName is the name of the ConfigMap in the ModelServe namespace
+	ConfigMapName string `json:"configMapName"`
+
+	// MountPath is the directory the ConfigMap keys are mounted into (e.g., "/config")
+	MountPath string `json:"mountPath"`
+}
+
+// NetworkPolicySpec configures the NetworkPolicy generated for the model pods
+type NetworkPolicySpec struct {
+	// Enabled generates a NetworkPolicy selecting the model pods
+	Enabled bool `json:"enabled"`
+
+	// IngressFrom are the peers allowed to reach the model server.
+	// Defaults to the Traefik and operator namespaces. The operator polls the
+	// pods for load progress and usage, so set peers must include it.
+	// +optional
+	IngressFrom []networkingv1.NetworkPolicyPeer `json:"ingressFrom,omitempty"`
+
+	// EgressTo are the peers the model pods may connect to, on any port.
+	// Defaults to MinIO and the monitoring database. The monitor sidecar
+	// installs its Python dependencies at startup, so clusters without a
+	// local package mirror must add a peer for it here.
+	// +optional
+	EgressTo []networkingv1.NetworkPolicyPeer `json:"egressTo,omitempty"`
+}
+
+// MonitoringSpec configures the monitor sidecar
+type MonitoringSpec struct {
+	// ExposeThroughGateway routes /<name>/metrics on the Ingress to the
+	// sidecar metrics port, behind the same auth middleware as the model
+	// +optional
+	ExposeThroughGateway bool `json:"exposeThroughGateway,omitempty"`
+
+	// MetricsEndpoint is the path of the server's own Prometheus metrics the
+	// sidecar and operator read token counts from. Defaults to /metrics.
+	// +optional
+	MetricsEndpoint string `json:"metricsEndpoint,omitempty"`
+
+	// DCGM adds a DCGM exporter sidecar reporting the GPU metrics of the pod
+	// and exposes its port on the Service. Ignored for models without GPUs.
+	// The sidecar runs as root with SYS_ADMIN and mounts the kubelet pod
+	// resources from the host, which namespaces enforcing the baseline or
+	// restricted Pod Security Standard reject.
+	// +optional
+	DCGM bool `json:"dcgm,omitempty"`
+}
+
+// StorageSpec configures the model volume. At most one of Size,
+// EphemeralSizeGi and SharedClaimName may be set.
+type StorageSpec struct {
+	// Size requests a PersistentVolumeClaim of this size (e.g., "20Gi") owned by
+	// the ModelServe, so the model survives pod restarts and is only downloaded once
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)?([KMGTPE]i|[kMGTPE])?$`
+	// +optional
+	Size string `json:"size,omitempty"`
+
+	// StorageClassName is the storage class of the claim created for Size
+	// +optional
+	StorageClassName *string `json:"storageClassName,omitempty"`
+
+	// EphemeralSizeGi is the size limit in GiB of the emptyDir model volume (default 10)
+	// +optional
+	EphemeralSizeGi int32 `json:"ephemeralSizeGi,omitempty"`
+
+	// SharedClaimName is an existing ReadWriteMany PVC used as a model cache
+	// shared across ModelServes. The model is downloaded once by a Job and
+	// pods serve it from the cache instead of downloading it themselves.
+	// +optional
+	SharedClaimName string `json:"sharedClaimName,omitempty"`
 }
 
 // ModelServeStatus defines the observed state of ModelServe
@@ -68,7 +538,7 @@ type ModelServeStatus struct {
 	// AvailableReplicas is the number of available replicas
 	AvailableReplicas int32 `json:"availableReplicas"`
 
-	// Phase is the current phase of the ModelServe (Pending, Downloading, Running, Failed)
+	// Phase is the current phase of the ModelServe (Pending, Preflighting, Standby, Downloading, Running, Failed)
 	Phase string `json:"phase,omitempty"`
 
 	// GatewayURL is the URL to access the model through the ingress
@@ -77,18 +547,81 @@ type ModelServeStatus struct {
 	// ServiceName is the name of the Kubernetes service
 	ServiceName string `json:"serviceName,omitempty"`
 
+	// Selector selects the model pods, for autoscalers using the scale subresource
+	Selector string `json:"selector,omitempty"`
+
+	// AppliedMiddlewares is the Traefik middleware chain of the model route
+	// in the order it applies
+	AppliedMiddlewares []string `json:"appliedMiddlewares,omitempty"`
+
 	// PodName is the name of the pod running the model
 	PodName string `json:"podName,omitempty"`
 
 	// StartedAt is when the model server started
 	StartedAt *metav1.Time `json:"startedAt,omitempty"`
 
+	// ActivatedAt is when a lazily downloaded model finished its first download
+	ActivatedAt *metav1.Time `json:"activatedAt,omitempty"`
+
+	// LoadingProgress is the latest model load progress in percent reported by
+	// spec.healthCheck.loadingEndpoint
+	LoadingProgress int32 `json:"loadingProgress,omitempty"`
+
+	// RetainedPod is the failed pod kept for debugging by spec.retainOnFailure
+	RetainedPod string `json:"retainedPod,omitempty"`
+
+	// ImageOverride notes that the operator runs a forced server image instead
+	// of spec.image
+	ImageOverride string `json:"imageOverride,omitempty"`
+
+	// RolloutInProgress holds one of the MAX_CONCURRENT_ROLLOUTS slots while
+	// the Deployment rolls a new pod template, until it rolled out, exceeded
+	// its progress deadline or the model failed
+	RolloutInProgress bool `json:"rolloutInProgress,omitempty"`
+
+	// RoutedBackends are the spec.backends receiving traffic from the router
+	RoutedBackends []string `json:"routedBackends,omitempty"`
+
+	// IgnoredPodLabels are the spec.podLabels dropped for conflicting with the
+	// labels the Service selects the pods by
+	IgnoredPodLabels []string `json:"ignoredPodLabels,omitempty"`
+
+	// ReplicaGroupConflicts are the spec.replicaGroups whose Deployment name
+	// is taken by an object the ModelServe does not own; they are left alone
+	ReplicaGroupConflicts []string `json:"replicaGroupConflicts,omitempty"`
+
+	// PromptTokens is the number of prompt tokens processed by the running pods
+	PromptTokens int64 `json:"promptTokens,omitempty"`
+
+	// GeneratedTokens is the number of tokens generated by the running pods
+	GeneratedTokens int64 `json:"generatedTokens,omitempty"`
+
+	// PeakMemoryMB is the highest memory usage in MB the monitor sidecar of a
+	// running pod reported
+	PeakMemoryMB int32 `json:"peakMemoryMB,omitempty"`
+
+	// LastModifiedBy is the user the last change to the ModelServe is
+	// attributed to, from the model.example.com/last-modified-by annotation
+	LastModifiedBy string `json:"lastModifiedBy,omitempty"`
+
+	// FailureReason classifies a failed download: MinIOUnavailable is retried
+	// with a backoff, DownloadFailed needs a spec change
+	FailureReason string `json:"failureReason,omitempty"`
+
+	// DownloadRetries counts the retries of downloads that failed while MinIO
+	// was unavailable
+	DownloadRetries int32 `json:"downloadRetries,omitempty"`
+
+	// NextRetryAt is when a transient download failure is retried next
+	NextRetryAt *metav1.Time `json:"nextRetryAt,omitempty"`
+
 	// Message provides additional information about the current status
 	Message string `json:"message,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.availableReplicas,selectorpath=.status.selector
 //+kubebuilder:printcolumn:name="Model",type=string,JSONPath=`.spec.modelName`
 //+kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
 //+kubebuilder:printcolumn:name="Replicas",type=integer,JSONPath=`.status.availableReplicas`
@@ -103,6 +636,36 @@ type ModelServe struct {
 	Status ModelServeStatus `json:"status,omitempty"`
 }
 
+// RoutePath returns the gateway path prefix of the model without a trailing slash
+func (m *ModelServe) RoutePath() string {
+	if p := strings.TrimSuffix(m.Spec.RoutePath, "/"); p != "" {
+		return p
+	}
+	return "/" + m.Name
+}
+
+// VariantFileName returns the file name of the selected quantization of the
+// model, or modelName when no quantization is set
+func (m *ModelServe) VariantFileName() string {
+	if m.Spec.Quantization == "" {
+		return m.Spec.ModelName
+	}
+	return strings.TrimSuffix(m.Spec.ModelName, ".gguf") + "." + m.Spec.Quantization + ".gguf"
+}
+
+// GPUs is the number of GPU devices the model requests, 0 when gpuCount is unset
+func (m *ModelServe) GPUs() int32 {
+	if m.Spec.GPUCount == nil {
+		return 0
+	}
+	return *m.Spec.GPUCount
+}
+
+// DefaultMinIOPath is the MinIO object path used when minioPath is not set
+func (m *ModelServe) DefaultMinIOPath() string {
+	return "models/" + m.VariantFileName()
+}
+
 //+kubebuilder:object:root=true
 
 // ModelServeList contains a list of ModelServe
@@ -17,17 +17,30 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
@@ -44,10 +57,20 @@ type JWTClaims struct {
 	Iat  int64  `json:"iat"`
 }
 
+// TenantLabel assigns a namespace, and the ModelServes in it, to a tenant
+const TenantLabel = "tenant"
+
+// webhookClient reads the cluster for validations spanning several objects.
+// It is nil until SetupWebhookWithManager runs, which skips those checks.
+var webhookClient client.Reader
+
 // SetupWebhookWithManager will setup the manager to manage the webhooks
 func (r *ModelServe) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	webhookClient = mgr.GetAPIReader()
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(r).
+		WithDefaulter(&modelServeDefaulter{}).
+		WithValidator(&modelServeValidator{}).
 		Complete()
 }
 
@@ -65,6 +88,9 @@ func (r *ModelServe) Default() {
 		r.Spec.Replicas = &replicas
 	}
 
+	// Profile values only fill fields the user left unset
+	r.applyProfile(context.Background())
+
 	if r.Spec.MemoryLimit == 0 {
 		r.Spec.MemoryLimit = 4096 // 4GB default
 	}
@@ -81,17 +107,308 @@ func (r *ModelServe) Default() {
 		r.Spec.MinIOEndpoint = "minio:9000"
 	}
 
+	// Same location the controller falls back to
+	if r.Spec.MinIOPath == "" && r.Spec.ModelName != "" {
+		r.Spec.MinIOPath = r.DefaultMinIOPath()
+	}
+
 	if r.Spec.Image == "" {
 		r.Spec.Image = "ghcr.io/ggerganov/llama.cpp:server"
 	}
+
+	// Reordering flags must not change the spec and roll the model
+	r.Spec.RuntimeParams = canonicalRuntimeParams(r.Spec.RuntimeParams)
+}
+
+// modelServeDefaulter applies Default and records who made the change, which
+// needs the admission request
+type modelServeDefaulter struct{}
+
+var _ webhook.CustomDefaulter = &modelServeDefaulter{}
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the type
+func (d *modelServeDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	r, ok := obj.(*ModelServe)
+	if !ok {
+		return fmt.Errorf("expected a ModelServe but got %T", obj)
+	}
+	r.rederiveMinIOPath(ctx)
+	r.Default()
+	r.recordModifier(ctx)
+	return nil
+}
+
+// rederiveMinIOPath clears a minioPath Default derived before, so it is derived
+// again when an update changes the modelName or quantization. A path the user
+// set differs from the derived one and is kept.
+func (r *ModelServe) rederiveMinIOPath(ctx context.Context) {
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil || len(req.OldObject.Raw) == 0 {
+		return
+	}
+	old := &ModelServe{}
+	if err := json.Unmarshal(req.OldObject.Raw, old); err != nil {
+		return
+	}
+	if r.Spec.MinIOPath != "" && r.Spec.MinIOPath == old.Spec.MinIOPath && old.Spec.MinIOPath == old.DefaultMinIOPath() {
+		r.Spec.MinIOPath = ""
+	}
+}
+
+// recordModifier records the last user to change the ModelServe. The subject
+// of the auth token only counts when this request set or replaced the token,
+// as a token left in place says nothing about who sent a later update, which
+// is then attributed to the requesting user. A previous or self-set value is
+// always replaced. An invalid token is rejected by validation anyway.
+func (r *ModelServe) recordModifier(ctx context.Context) {
+	by := ""
+	if req, err := admission.RequestFromContext(ctx); err == nil {
+		by = req.UserInfo.Username
+		if token := r.Annotations[authTokenAnnotation]; token != "" && token != previousAuthToken(req) {
+			claims, err := r.verifyJWT()
+			if err != nil {
+				return
+			}
+			by = claims.Sub
+		}
+	}
+
+	if by == "" {
+		delete(r.Annotations, LastModifiedByAnnotation)
+		return
+	}
+	if r.Annotations == nil {
+		r.Annotations = map[string]string{}
+	}
+	r.Annotations[LastModifiedByAnnotation] = by
+}
+
+// previousAuthToken returns the auth token of the ModelServe before an update,
+// empty on create
+func previousAuthToken(req admission.Request) string {
+	if len(req.OldObject.Raw) == 0 {
+		return ""
+	}
+	old := &ModelServe{}
+	if err := json.Unmarshal(req.OldObject.Raw, old); err != nil {
+		return ""
+	}
+	return old.Annotations[authTokenAnnotation]
+}
+
+// runtimeFlagAliases maps short llama-server flags to their long form
+var runtimeFlagAliases = map[string]string{
+	"-b":   "--batch-size",
+	"-c":   "--ctx-size",
+	"-ngl": "--n-gpu-layers",
+	"-np":  "--parallel",
+	"-t":   "--threads",
+}
+
+// canonicalRuntimeParams sorts runtime params by flag, keeping each flag
+// with its values and repeated flags in their relative order, and drops exact
+// duplicates except the last. Short aliases are spelled out first, so a flag
+// given both ways keeps the order the server resolves it in. Arguments before
+// the first flag stay in front.
+func canonicalRuntimeParams(params string) string {
+	type flag struct {
+		name string
+		args []string
+	}
+	var leading []string
+	var flags []flag
+	for _, field := range strings.Fields(params) {
+		if isRuntimeFlag(field) {
+			name, value, hasValue := strings.Cut(field, "=")
+			if long, ok := runtimeFlagAliases[name]; ok {
+				name, field = long, long
+				if hasValue {
+					field += "=" + value
+				}
+			}
+			flags = append(flags, flag{name: name, args: []string{field}})
+		} else if len(flags) == 0 {
+			leading = append(leading, field)
+		} else {
+			flags[len(flags)-1].args = append(flags[len(flags)-1].args, field)
+		}
+	}
+
+	// A later copy of the same flag and values wins, as it does on the server
+	last := map[string]int{}
+	for i, f := range flags {
+		last[strings.Join(f.args, " ")] = i
+	}
+	unique := flags[:0]
+	for i, f := range flags {
+		if last[strings.Join(f.args, " ")] == i {
+			unique = append(unique, f)
+		}
+	}
+	sort.SliceStable(unique, func(i, j int) bool { return unique[i].name < unique[j].name })
+
+	out := leading
+	for _, f := range unique {
+		out = append(out, f.args...)
+	}
+	return strings.Join(out, " ")
+}
+
+// isRuntimeFlag reports whether a runtime param starts a flag rather than
+// being a value such as a negative number
+func isRuntimeFlag(field string) bool {
+	if !strings.HasPrefix(field, "-") || field == "-" {
+		return false
+	}
+	_, err := strconv.ParseFloat(field, 64)
+	return err != nil
 }
 
 //+kubebuilder:webhook:path=/validate-model-example-com-v1alpha1-modelserve,mutating=false,failurePolicy=fail,sideEffects=None,groups=model.example.com,resources=modelserves,verbs=create;update;delete,versions=v1alpha1,name=vmodelserve.kb.io,admissionReviewVersions=v1
 
-var _ webhook.Validator = &ModelServe{}
+// modelServeValidator validates ModelServes with access to the admission
+// request, so checks with side effects can be skipped on dry-run requests
+type modelServeValidator struct{}
+
+var _ webhook.CustomValidator = &modelServeValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *modelServeValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	r, ok := obj.(*ModelServe)
+	if !ok {
+		return nil, fmt.Errorf("expected a ModelServe but got %T", obj)
+	}
+
+	warnings, err := r.validateCreate(ctx)
+	if err != nil || isDryRun(ctx) {
+		return warnings, err
+	}
+	return append(warnings, r.checkMinIOReachable(ctx)...), nil
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *modelServeValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	r, ok := newObj.(*ModelServe)
+	if !ok {
+		return nil, fmt.Errorf("expected a ModelServe but got %T", newObj)
+	}
+
+	// The activator of a lazy model only asks for the model, with whatever
+	// auth token the model was created with, so an expired one must not keep
+	// the model from starting
+	if old, ok := oldObj.(*ModelServe); ok && activationOnly(old, r) {
+		return nil, nil
+	}
+	if byActivator(ctx, r) {
+		return nil, fmt.Errorf("the activator of %s may only set the %s annotation", r.Name, ActivationRequestedAnnotation)
+	}
+
+	warnings, err := r.validateUpdate(ctx, oldObj)
+	if err != nil || isDryRun(ctx) {
+		return warnings, err
+	}
+
+	// Only a changed endpoint is worth probing again
+	if old, ok := oldObj.(*ModelServe); ok && old.Spec.MinIOEndpoint == r.Spec.MinIOEndpoint {
+		return warnings, nil
+	}
+	return append(warnings, r.checkMinIOReachable(ctx)...), nil
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *modelServeValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	r, ok := obj.(*ModelServe)
+	if !ok {
+		return nil, fmt.Errorf("expected a ModelServe but got %T", obj)
+	}
+	return r.validateDelete(ctx)
+}
+
+// activationOnly reports whether an update only sets the activation annotation,
+// besides the modifier recorded by the defaulting webhook
+func activationOnly(old, r *ModelServe) bool {
+	if r.Annotations[ActivationRequestedAnnotation] == old.Annotations[ActivationRequestedAnnotation] {
+		return false
+	}
+	if !equality.Semantic.DeepEqual(old.Spec, r.Spec) || !equality.Semantic.DeepEqual(old.Labels, r.Labels) {
+		return false
+	}
+
+	others := func(annotations map[string]string) map[string]string {
+		out := map[string]string{}
+		for k, v := range annotations {
+			if k != ActivationRequestedAnnotation && k != LastModifiedByAnnotation {
+				out[k] = v
+			}
+		}
+		return out
+	}
+	return equality.Semantic.DeepEqual(others(old.Annotations), others(r.Annotations))
+}
+
+// byActivator reports whether the admission request in ctx comes from the
+// activator of the ModelServe
+func byActivator(ctx context.Context, r *ModelServe) bool {
+	req, err := admission.RequestFromContext(ctx)
+	return err == nil && req.UserInfo.Username == "system:serviceaccount:"+r.Namespace+":"+ActivatorServiceAccountName(r.Name)
+}
+
+// validateCluster holds the checks reading other objects from the cluster.
+// Dry-run requests skip the Deployment name check listing every namespace,
+// like the MinIO reachability check, but keep the class, policy and tenant
+// route checks, so a dry-run rejects what the real request would.
+func (r *ModelServe) validateCluster(ctx context.Context) error {
+	if !isDryRun(ctx) {
+		if err := r.validateDeploymentNames(ctx); err != nil {
+			return err
+		}
+	}
+
+	if err := r.validateClass(ctx); err != nil {
+		return err
+	}
+
+	if err := r.validatePolicies(ctx); err != nil {
+		return err
+	}
+
+	return r.validateTenantRoute(ctx)
+}
+
+// isDryRun reports whether the admission request in ctx is a dry-run
+func isDryRun(ctx context.Context) bool {
+	req, err := admission.RequestFromContext(ctx)
+	return err == nil && req.DryRun != nil && *req.DryRun
+}
+
+// dialMinIO opens a connection to the MinIO endpoint, replaced in tests
+var dialMinIO = defaultDialMinIO
+
+func defaultDialMinIO(ctx context.Context, address string) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
 
-// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
-func (r *ModelServe) ValidateCreate() (admission.Warnings, error) {
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// checkMinIOReachable warns when the MinIO endpoint cannot be reached. The pods
+// may still reach it from their own network, so this never rejects the object.
+func (r *ModelServe) checkMinIOReachable(ctx context.Context) admission.Warnings {
+	if r.Spec.MinIOEndpoint == "" {
+		return nil
+	}
+	if err := dialMinIO(ctx, r.Spec.MinIOEndpoint); err != nil {
+		return admission.Warnings{fmt.Sprintf("minioEndpoint %s is not reachable from the operator: %v", r.Spec.MinIOEndpoint, err)}
+	}
+	return nil
+}
+
+// validateCreate holds the field validation of a new ModelServe
+func (r *ModelServe) validateCreate(ctx context.Context) (admission.Warnings, error) {
 	modelservelog.Info("validate create", "name", r.Name)
 
 	// Validate JWT from annotation if present
@@ -117,6 +434,10 @@ func (r *ModelServe) ValidateCreate() (admission.Warnings, error) {
 		return nil, fmt.Errorf("replicas cannot exceed 5")
 	}
 
+	if r.Spec.WarmPool < 0 || r.Spec.WarmPool > 5 {
+		return nil, fmt.Errorf("warmPool must be between 0 and 5")
+	}
+
 	// Validate memory limit
 	if r.Spec.MemoryLimit > 32768 {
 		return nil, fmt.Errorf("memoryLimit cannot exceed 32768 MB (32GB)")
@@ -127,11 +448,55 @@ func (r *ModelServe) ValidateCreate() (admission.Warnings, error) {
 		return nil, fmt.Errorf("cpuLimit cannot exceed 16000m (16 cores)")
 	}
 
-	return nil, nil
+	if err := r.validateConfigFile(); err != nil {
+		return nil, err
+	}
+
+	if err := r.validateStartupScript(); err != nil {
+		return nil, err
+	}
+
+	if err := r.validateStorage(); err != nil {
+		return nil, err
+	}
+
+	if err := r.validateDownloadMode(); err != nil {
+		return nil, err
+	}
+
+	if err := r.validateGPUResource(); err != nil {
+		return nil, err
+	}
+
+	if err := r.validateExtraPorts(); err != nil {
+		return nil, err
+	}
+
+	if err := r.validateBackends(); err != nil {
+		return nil, err
+	}
+
+	if err := r.validateReplicaGroups(); err != nil {
+		return nil, err
+	}
+
+	if err := r.validateCluster(ctx); err != nil {
+		return nil, err
+	}
+
+	if r.Spec.ParallelSlots != nil && *r.Spec.ParallelSlots < 1 {
+		return nil, fmt.Errorf("parallelSlots must be positive")
+	}
+
+	if r.Spec.CompletionProbe != nil && r.Spec.CompletionProbe.TimeoutSeconds < 0 {
+		return nil, fmt.Errorf("completionProbe.timeoutSeconds cannot be negative")
+	}
+
+	return r.parallelSlotsWarnings(), nil
 }
 
-// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
-func (r *ModelServe) ValidateUpdate(old runtime.Object) (admission.Warnings, error) {
+// validateUpdate holds the field validation of an updated ModelServe
+func (r *ModelServe) validateUpdate(ctx context.Context, old runtime.Object) (admission.Warnings, error) {
 	modelservelog.Info("validate update", "name", r.Name)
 
 	// Validate JWT from annotation if present
@@ -144,11 +509,92 @@ func (r *ModelServe) ValidateUpdate(old runtime.Object) (admission.Warnings, err
 		return nil, fmt.Errorf("replicas cannot exceed 5")
 	}
 
-	return nil, nil
+	if r.Spec.WarmPool < 0 || r.Spec.WarmPool > 5 {
+		return nil, fmt.Errorf("warmPool must be between 0 and 5")
+	}
+
+	if err := r.validateConfigFile(); err != nil {
+		return nil, err
+	}
+
+	if err := r.validateStartupScript(); err != nil {
+		return nil, err
+	}
+
+	if err := r.validateStorage(); err != nil {
+		return nil, err
+	}
+
+	if err := r.validateDownloadMode(); err != nil {
+		return nil, err
+	}
+
+	if err := r.validateGPUResource(); err != nil {
+		return nil, err
+	}
+
+	if err := r.validateExtraPorts(); err != nil {
+		return nil, err
+	}
+
+	if err := r.validateBackends(); err != nil {
+		return nil, err
+	}
+
+	if err := r.validateReplicaGroups(); err != nil {
+		return nil, err
+	}
+
+	if err := r.validateCluster(ctx); err != nil {
+		return nil, err
+	}
+
+	if r.Spec.ParallelSlots != nil && *r.Spec.ParallelSlots < 1 {
+		return nil, fmt.Errorf("parallelSlots must be positive")
+	}
+
+	if r.Spec.CompletionProbe != nil && r.Spec.CompletionProbe.TimeoutSeconds < 0 {
+		return nil, fmt.Errorf("completionProbe.timeoutSeconds cannot be negative")
+	}
+
+	return append(r.memoryLimitWarnings(old), r.parallelSlotsWarnings()...), nil
 }
 
-// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
-func (r *ModelServe) ValidateDelete() (admission.Warnings, error) {
+// memoryLimitWarnings warns when an update lowers memoryLimit below the
+// memory the running model was observed using
+func (r *ModelServe) memoryLimitWarnings(old runtime.Object) admission.Warnings {
+	prev, ok := old.(*ModelServe)
+	if !ok || r.Spec.MemoryLimit == 0 || r.Spec.MemoryLimit == prev.Spec.MemoryLimit {
+		return nil
+	}
+	if peak := prev.Status.PeakMemoryMB; peak > r.Spec.MemoryLimit {
+		return admission.Warnings{fmt.Sprintf(
+			"memoryLimit %dMB is below the %dMB the running model was observed using; the pods will be OOM killed after the next rollout",
+			r.Spec.MemoryLimit, peak)}
+	}
+	return nil
+}
+
+// memoryPerParallelSlotMB is the memory a request slot is expected to need on
+// top of the model, mostly for its share of the KV cache and batch buffers
+const memoryPerParallelSlotMB = 256
+
+// parallelSlotsWarnings warns when the request slots would not fit the memory
+// limit of the server
+func (r *ModelServe) parallelSlotsWarnings() admission.Warnings {
+	if r.Spec.ParallelSlots == nil || r.Spec.MemoryLimit == 0 {
+		return nil
+	}
+	if slots := *r.Spec.ParallelSlots; slots*memoryPerParallelSlotMB > r.Spec.MemoryLimit {
+		return admission.Warnings{fmt.Sprintf(
+			"parallelSlots %d is high for memoryLimit %dMB; expect about %dMB per slot on top of the model",
+			slots, r.Spec.MemoryLimit, memoryPerParallelSlotMB)}
+	}
+	return nil
+}
+
+// validateDelete checks the caller may delete the ModelServe
+func (r *ModelServe) validateDelete(ctx context.Context) (admission.Warnings, error) {
 	modelservelog.Info("validate delete", "name", r.Name)
 
 	// Validate JWT from annotation if present
@@ -159,8 +605,438 @@ func (r *ModelServe) ValidateDelete() (admission.Warnings, error) {
 	return nil, nil
 }
 
+// validateConfigFile validates the optional ConfigMap file mount
+func (r *ModelServe) validateConfigFile() error {
+	cf := r.Spec.ConfigFile
+	if cf == nil {
+		return nil
+	}
+
+	if cf.ConfigMapName == "" {
+		return fmt.Errorf("configFile.configMapName is required")
+	}
+
+	if !strings.HasPrefix(cf.MountPath, "/") {
+		return fmt.Errorf("configFile.mountPath must be an absolute path")
+	}
+
+	// The model volume is mounted at /models and must not be shadowed
+	mountPath := strings.TrimSuffix(cf.MountPath, "/")
+	if mountPath == "" || mountPath == "/models" || strings.HasPrefix(mountPath, "/models/") {
+		return fmt.Errorf("configFile.mountPath cannot be / or overlap /models")
+	}
+
+	return nil
+}
+
+// validateStartupScript rejects startup scripts running the server. The
+// script runs as an init step, so a server started from it would block the pod
+// from ever starting the real server container. Only commands are checked, a
+// mention of llama-server in an argument or comment is fine; anything else
+// the script must not leave running is up to its author.
+func (r *ModelServe) validateStartupScript() error {
+	for _, line := range strings.Split(r.Spec.StartupScript, "\n") {
+		fields := strings.Fields(line)
+		for len(fields) > 0 && (fields[0] == "exec" || fields[0] == "nohup") {
+			fields = fields[1:]
+		}
+		if len(fields) > 0 && path.Base(fields[0]) == "llama-server" {
+			return fmt.Errorf("startupScript must not start llama-server; use runtimeParams to change server flags")
+		}
+	}
+	return nil
+}
+
+// validateStorage ensures a single model volume type is selected
+func (r *ModelServe) validateStorage() error {
+	st := r.Spec.Storage
+	if st == nil {
+		return nil
+	}
+
+	var set []string
+	if st.Size != "" {
+		set = append(set, "storage.size")
+	}
+	if st.EphemeralSizeGi != 0 {
+		set = append(set, "storage.ephemeralSizeGi")
+	}
+	if st.SharedClaimName != "" {
+		set = append(set, "storage.sharedClaimName")
+	}
+	if len(set) > 1 {
+		return fmt.Errorf("%s are mutually exclusive: use storage.size for a persistent volume, "+
+			"storage.ephemeralSizeGi for an emptyDir or storage.sharedClaimName for the shared cache", strings.Join(set, " and "))
+	}
+
+	if st.Size != "" {
+		if _, err := resource.ParseQuantity(st.Size); err != nil {
+			return fmt.Errorf("invalid storage.size %q: %v", st.Size, err)
+		}
+	}
+
+	if st.EphemeralSizeGi < 0 {
+		return fmt.Errorf("storage.ephemeralSizeGi must be positive")
+	}
+
+	return nil
+}
+
+// validateDownloadMode ensures a lazily downloaded or re-verified model has
+// a persistent volume to land on and a custom download command is usable
+func (r *ModelServe) validateDownloadMode() error {
+	persistent := r.Spec.Storage != nil && r.Spec.Storage.Size != ""
+
+	// The download Job and the server pod only share a persistent volume
+	if r.Spec.ModelDownloadMode == ModelDownloadModeLazy && !persistent {
+		return fmt.Errorf("modelDownloadMode Lazy requires storage.size")
+	}
+
+	if dl := r.Spec.Download; dl != nil && dl.SHA256 != "" && dl.ChecksumObject != "" {
+		return fmt.Errorf("download.sha256 and download.checksumObject are mutually exclusive")
+	}
+
+	if dl := r.Spec.Download; dl != nil && dl.VerifyOnStart {
+		if dl.SHA256 == "" {
+			return fmt.Errorf("download.verifyOnStart requires download.sha256")
+		}
+		if !persistent {
+			return fmt.Errorf("download.verifyOnStart requires storage.size; other volumes download the model on every start")
+		}
+	}
+
+	if dl := r.Spec.Download; dl != nil {
+		if err := validateDownloadProxy(dl); err != nil {
+			return err
+		}
+	}
+
+	if dl := r.Spec.Download; dl != nil && dl.Command != "" {
+		return validateDownloadCommand(dl.Command)
+	}
+
+	return nil
+}
+
+// validateDownloadProxy checks the proxy settings of the download are usable
+// as HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+func validateDownloadProxy(dl *DownloadSpec) error {
+	proxies := []struct{ field, url string }{{"httpProxy", dl.HTTPProxy}, {"httpsProxy", dl.HTTPSProxy}}
+	for _, proxy := range proxies {
+		if proxy.url == "" {
+			continue
+		}
+		u, err := url.Parse(proxy.url)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("download.%s: %q is not an http:// or https:// proxy URL", proxy.field, proxy.url)
+		}
+	}
+
+	if dl.NoProxy == "" {
+		return nil
+	}
+	for _, entry := range strings.Split(dl.NoProxy, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || strings.ContainsAny(entry, " \t") {
+			return fmt.Errorf("download.noProxy: invalid entry %q", entry)
+		}
+		if strings.Contains(entry, "/") {
+			if _, _, err := net.ParseCIDR(entry); err != nil {
+				return fmt.Errorf("download.noProxy: invalid CIDR %q", entry)
+			}
+		}
+	}
+	return nil
+}
+
+// downloadDestSentinel stands in for {{.Dest}} while validating a command
+const downloadDestSentinel = "/models/.modelserve-download-dest"
+
+// validateDownloadCommand rejects custom download commands the cluster did
+// not enable, that do not render, or that do not write to {{.Dest}}
+func validateDownloadCommand(command string) error {
+	if !CustomDownloadCommandAllowed() {
+		return fmt.Errorf("download.command is disabled on this cluster (set %s=true on the operator)", CustomDownloadCommandEnv)
+	}
+	rendered, err := RenderDownloadCommand(command, DownloadCommandVars{
+		ModelName: "model",
+		Endpoint:  "minio:9000",
+		Bucket:    "bucket",
+		Path:      "models/model",
+		Dest:      downloadDestSentinel,
+	})
+	if err != nil {
+		return fmt.Errorf("download.command is not a valid template: %v", err)
+	}
+	if !strings.Contains(rendered, downloadDestSentinel) {
+		return fmt.Errorf("download.command must write the model to {{.Dest}} under the /models mount")
+	}
+	return nil
+}
+
+// allowedGPUResources are the whole and time-sliced GPU resources the device
+// plugin advertises; MIG slices are matched by migResourcePattern
+var allowedGPUResources = map[string]bool{
+	"nvidia.com/gpu":        true,
+	"nvidia.com/gpu.shared": true,
+}
+
+// migResourcePattern matches MIG slice resources such as nvidia.com/mig-1g.5gb
+var migResourcePattern = regexp.MustCompile(`^nvidia\.com/mig-[1-7]g\.[0-9]+gb$`)
+
+// validateGPUResource rejects GPU resource names no device plugin serves and
+// GPU sharing groups requesting whole GPUs
+func (r *ModelServe) validateGPUResource() error {
+	name := r.Spec.GPUResourceName
+	if name != "" && !allowedGPUResources[name] && !migResourcePattern.MatchString(name) {
+		return fmt.Errorf("gpuResourceName %q is not supported: use nvidia.com/gpu, nvidia.com/gpu.shared or a MIG slice such as nvidia.com/mig-1g.5gb", name)
+	}
+
+	if r.Spec.GPUSharing == nil {
+		return nil
+	}
+	if errs := validation.IsValidLabelValue(r.Spec.GPUSharing.Group); r.Spec.GPUSharing.Group == "" || len(errs) > 0 {
+		return fmt.Errorf("gpuSharing.group %q must be a non-empty label value", r.Spec.GPUSharing.Group)
+	}
+	// Whole GPUs are exclusive to one pod and cannot be shared
+	if r.GPUs() == 0 || name == "" || name == "nvidia.com/gpu" {
+		return fmt.Errorf("gpuSharing requires gpuCount and a shareable gpuResourceName such as nvidia.com/gpu.shared or a MIG slice")
+	}
+	return nil
+}
+
+// validateExtraPorts rejects extra ports colliding with the managed ports or each other
+func (r *ModelServe) validateExtraPorts() error {
+	// The server listens on 8080 and the monitor sidecar serves metrics on 9090
+	ports := map[int32]string{8080: "http", 9090: "metrics"}
+	names := map[string]bool{"http": true, "metrics": true}
+
+	for _, p := range r.Spec.ExtraPorts {
+		if p.Name == "" {
+			return fmt.Errorf("extraPorts: port %d needs a name", p.ContainerPort)
+		}
+		if names[p.Name] {
+			return fmt.Errorf("extraPorts: port name %q is already in use", p.Name)
+		}
+		if p.ContainerPort < 1 || p.ContainerPort > 65535 {
+			return fmt.Errorf("extraPorts: port %s has invalid number %d", p.Name, p.ContainerPort)
+		}
+		if other, ok := ports[p.ContainerPort]; ok {
+			return fmt.Errorf("extraPorts: port %s collides with port %s on %d", p.Name, other, p.ContainerPort)
+		}
+		ports[p.ContainerPort] = p.Name
+		names[p.Name] = true
+	}
+
+	return nil
+}
+
+// validateBackends checks the router backends name distinct ModelServes and
+// leave at least one of them a share of the traffic
+func (r *ModelServe) validateBackends() error {
+	if len(r.Spec.Backends) == 0 {
+		return nil
+	}
+
+	seen := map[string]bool{}
+	var total int32
+	for _, b := range r.Spec.Backends {
+		if errs := validation.IsDNS1123Label(b.Name); len(errs) > 0 {
+			return fmt.Errorf("backends: invalid ModelServe name %q: %s", b.Name, strings.Join(errs, ", "))
+		}
+		if seen[b.Name] {
+			return fmt.Errorf("backends: %s is listed more than once", b.Name)
+		}
+		if b.Weight < 0 {
+			return fmt.Errorf("backends: %s has negative weight %d", b.Name, b.Weight)
+		}
+		seen[b.Name] = true
+		total += b.Weight
+	}
+
+	if total == 0 {
+		return fmt.Errorf("backends: at least one backend needs a positive weight")
+	}
+	return nil
+}
+
+// validateReplicaGroups checks the replica groups have distinct names usable
+// in Deployment names and stay within the replica limit of the model
+func (r *ModelServe) validateReplicaGroups() error {
+	seen := map[string]bool{}
+	for _, g := range r.Spec.ReplicaGroups {
+		if errs := validation.IsDNS1123Label(g.Name); len(errs) > 0 {
+			return fmt.Errorf("replicaGroups: invalid name %q: %s", g.Name, strings.Join(errs, ", "))
+		}
+		if seen[g.Name] {
+			return fmt.Errorf("replicaGroups: %s is listed more than once", g.Name)
+		}
+		seen[g.Name] = true
+		if g.Replicas < 0 || g.Replicas > 5 {
+			return fmt.Errorf("replicaGroups: %s replicas must be between 0 and 5", g.Name)
+		}
+		if g.MemoryLimit < 0 || g.CPULimit < 0 || g.GPUCount < 0 || g.ContextSize < 0 {
+			return fmt.Errorf("replicaGroups: %s has a negative resource override", g.Name)
+		}
+	}
+	return nil
+}
+
+// deploymentNames returns the names of the Deployments the controller keeps
+// for the ModelServe: its own and one per replica group
+func (r *ModelServe) deploymentNames() []string {
+	names := []string{r.Name}
+	for _, g := range r.Spec.ReplicaGroups {
+		names = append(names, r.Name+"-"+g.Name)
+	}
+	return names
+}
+
+// validateDeploymentNames rejects ModelServes whose Deployments would share
+// a name with those of another ModelServe of the namespace, such as group
+// small of llama next to a ModelServe llama-small
+func (r *ModelServe) validateDeploymentNames(ctx context.Context) error {
+	if webhookClient == nil {
+		return nil
+	}
+
+	ours := map[string]bool{}
+	for _, name := range r.deploymentNames() {
+		ours[name] = true
+	}
+
+	modelServes := &ModelServeList{}
+	if err := webhookClient.List(ctx, modelServes, client.InNamespace(r.Namespace)); err != nil {
+		return fmt.Errorf("failed to list ModelServes for Deployment name conflicts: %v", err)
+	}
+	for i := range modelServes.Items {
+		other := &modelServes.Items[i]
+		if other.Name == r.Name {
+			continue
+		}
+		for _, name := range other.deploymentNames() {
+			if ours[name] {
+				return fmt.Errorf("replicaGroups: Deployment %s is also used by ModelServe %s", name, other.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// validateTenantRoute keeps the route of a tenant's model under the tenant
+// prefix and rejects routes colliding with another tenant's models. The tenant
+// comes from the namespace label, which tenants cannot set themselves.
+func (r *ModelServe) validateTenantRoute(ctx context.Context) error {
+	if webhookClient == nil {
+		return nil
+	}
+
+	tenants := map[string]string{}
+	tenantOf := func(namespace string) (string, error) {
+		if tenant, ok := tenants[namespace]; ok {
+			return tenant, nil
+		}
+		ns := &corev1.Namespace{}
+		if err := webhookClient.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+			return "", fmt.Errorf("failed to look up tenant of namespace %s: %v", namespace, err)
+		}
+		tenants[namespace] = ns.Labels[TenantLabel]
+		return tenants[namespace], nil
+	}
+
+	tenant, err := tenantOf(r.Namespace)
+	if err != nil {
+		return err
+	}
+	if label, ok := r.Labels[TenantLabel]; ok && label != tenant {
+		return fmt.Errorf("tenant label %q does not match tenant %q of namespace %s", label, tenant, r.Namespace)
+	}
+
+	route := r.RoutePath()
+	if tenant != "" && !strings.HasPrefix(route, "/"+tenant+"/") {
+		return fmt.Errorf("routePath %s is outside the prefix of tenant %s; set spec.routePath under /%s/", route, tenant, tenant)
+	}
+
+	modelServes := &ModelServeList{}
+	if err := webhookClient.List(ctx, modelServes); err != nil {
+		return fmt.Errorf("failed to list ModelServes for route conflicts: %v", err)
+	}
+	for i := range modelServes.Items {
+		other := &modelServes.Items[i]
+		if other.Namespace == r.Namespace && other.Name == r.Name {
+			continue
+		}
+		if !routeOverlaps(route, other.RoutePath()) {
+			continue
+		}
+		otherTenant, err := tenantOf(other.Namespace)
+		if err != nil {
+			return err
+		}
+		if otherTenant != tenant {
+			return fmt.Errorf("routePath %s collides with %s of ModelServe %s/%s owned by tenant %q",
+				route, other.RoutePath(), other.Namespace, other.Name, otherTenant)
+		}
+	}
+
+	return nil
+}
+
+// routeOverlaps reports whether one route is a path-segment prefix of the
+// other, in which case the gateway sends some requests of one to the other
+func routeOverlaps(a, b string) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	return a == b || strings.HasPrefix(b, a+"/")
+}
+
+// requiredJWTClaims must be present in every token
+var requiredJWTClaims = []string{"sub", "type", "exp", "iat"}
+
+// checkRequiredClaims reports the first required claim absent from the payload
+func checkRequiredClaims(payload []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &raw); err != nil {
+		return fmt.Errorf("invalid JWT claims: %v", err)
+	}
+	for _, claim := range requiredJWTClaims {
+		if v, ok := raw[claim]; !ok || string(v) == "null" {
+			return fmt.Errorf("JWT is missing required claim %q", claim)
+		}
+	}
+	return nil
+}
+
+// defaultJWTTypes are the token types accepted when JWT_ALLOWED_TYPES is unset
+var defaultJWTTypes = []string{"internal", "user"}
+
+// allowedJWTTypes returns the token types from the comma separated
+// JWT_ALLOWED_TYPES, or the default types
+func allowedJWTTypes() map[string]bool {
+	names := defaultJWTTypes
+	if env := os.Getenv("JWT_ALLOWED_TYPES"); strings.TrimSpace(env) != "" {
+		names = strings.Split(env, ",")
+	}
+
+	allowed := map[string]bool{}
+	for _, t := range names {
+		if t = strings.TrimSpace(t); t != "" {
+			allowed[t] = true
+		}
+	}
+	return allowed
+}
+
 // validateJWT validates the JWT token in the annotation
 func (r *ModelServe) validateJWT() error {
+	_, err := r.verifyJWT()
+	return err
+}
+
+// verifyJWT verifies the JWT token in the annotation and returns its claims,
+// or nil claims when there is no token
+func (r *ModelServe) verifyJWT() (*JWTClaims, error) {
 	// Get JWT secret from environment
 	jwtSecret := os.Getenv("JWT_SECRET")
 	if jwtSecret == "" {
@@ -168,34 +1044,34 @@ func (r *ModelServe) validateJWT() error {
 	}
 
 	// Check for JWT in annotation
-	token, ok := r.Annotations["model.example.com/auth-token"]
+	token, ok := r.Annotations[authTokenAnnotation]
 	if !ok {
 		// If no token annotation, skip JWT validation (rely on RBAC)
 		modelservelog.Info("No auth-token annotation, skipping JWT validation")
-		return nil
+		return nil, nil
 	}
 
 	// Parse JWT
 	parts := strings.Split(token, ".")
 	if len(parts) != 3 {
-		return fmt.Errorf("invalid JWT format")
+		return nil, fmt.Errorf("invalid JWT format")
 	}
 
 	// Decode header (not used but validate it exists)
 	_, err := base64.RawURLEncoding.DecodeString(parts[0])
 	if err != nil {
-		return fmt.Errorf("invalid JWT header: %v", err)
+		return nil, fmt.Errorf("invalid JWT header: %v", err)
 	}
 
 	// Decode payload
 	payloadBytes, err := base64.RawURLEncoding.DecodeString(parts[1])
 	if err != nil {
-		return fmt.Errorf("invalid JWT payload: %v", err)
+		return nil, fmt.Errorf("invalid JWT payload: %v", err)
 	}
 
 	var claims JWTClaims
 	if err := json.Unmarshal(payloadBytes, &claims); err != nil {
-		return fmt.Errorf("invalid JWT claims: %v", err)
+		return nil, fmt.Errorf("invalid JWT claims: %v", err)
 	}
 
 	// Verify signature
@@ -205,19 +1081,30 @@ func (r *ModelServe) validateJWT() error {
 	expectedSignature := base64.RawURLEncoding.EncodeToString(h.Sum(nil))
 
 	if parts[2] != expectedSignature {
-		return fmt.Errorf("invalid JWT signature")
+		return nil, fmt.Errorf("invalid JWT signature")
+	}
+
+	// Zero values of missing claims must not pass the checks below
+	if err := checkRequiredClaims(payloadBytes); err != nil {
+		return nil, err
+	}
+	if claims.Sub == "" {
+		return nil, fmt.Errorf("JWT claim sub must not be empty")
+	}
+	if claims.Exp <= 0 {
+		return nil, fmt.Errorf("JWT claim exp must be a positive expiry time")
 	}
 
 	// Check expiration
 	if claims.Exp < time.Now().Unix() {
-		return fmt.Errorf("JWT token has expired")
+		return nil, fmt.Errorf("JWT token has expired")
 	}
 
 	// Validate token type
-	if claims.Type != "internal" && claims.Type != "user" {
-		return fmt.Errorf("invalid token type: %s", claims.Type)
+	if !allowedJWTTypes()[claims.Type] {
+		return nil, fmt.Errorf("invalid token type: %s", claims.Type)
 	}
 
 	modelservelog.Info("JWT validated successfully", "sub", claims.Sub, "type", claims.Type)
-	return nil
+	return &claims, nil
 }
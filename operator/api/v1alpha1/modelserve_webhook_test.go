@@ -0,0 +1,1014 @@
+package v1alpha1
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// newTestModelServe returns a ModelServe passing the required field validation
+func newTestModelServe() *ModelServe {
+	return &ModelServe{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		Spec: ModelServeSpec{
+			ModelName: "test.gguf",
+			ModelUUID: "test-uuid",
+			MinIOPath: "models/test.gguf",
+		},
+	}
+}
+
+func TestValidateStorage(t *testing.T) {
+	tests := []struct {
+		name    string
+		storage *StorageSpec
+		wantErr string
+	}{
+		{name: "persistent volume", storage: &StorageSpec{Size: "20Gi"}},
+		{name: "emptyDir", storage: &StorageSpec{EphemeralSizeGi: 20}},
+		{name: "shared cache", storage: &StorageSpec{SharedClaimName: "model-cache"}},
+		{
+			name:    "persistent volume and emptyDir",
+			storage: &StorageSpec{Size: "20Gi", EphemeralSizeGi: 20},
+			wantErr: "storage.size and storage.ephemeralSizeGi are mutually exclusive",
+		},
+		{
+			name:    "invalid size",
+			storage: &StorageSpec{Size: "lots"},
+			wantErr: "invalid storage.size",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := newTestModelServe()
+			ms.Spec.Storage = tt.storage
+
+			_, err := ms.validateCreate(context.Background())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestModelServeClassDefaultsAndPolicy(t *testing.T) {
+	s := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	if err := AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	class := &ModelServeClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "standard"},
+		Spec: ModelServeClassSpec{
+			AllowedImages:  []string{"ghcr.io/ggerganov/llama.cpp:*"},
+			MaxMemoryLimit: 8192,
+			MaxGPUCount:    1,
+			Storage:        &StorageSpec{Size: "20Gi"},
+			NodeSelector:   map[string]string{"pool": "inference", "zone": "a"},
+		},
+	}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}
+	webhookClient = fake.NewClientBuilder().WithScheme(s).WithObjects(class, namespace).Build()
+	t.Cleanup(func() { webhookClient = nil })
+
+	// Class defaults fill unset fields, fields set on the model win. They are
+	// left to the controller, so admission does not persist them.
+	ms := newTestModelServe()
+	ms.Spec.ClassName = "standard"
+	ms.Spec.NodeSelector = map[string]string{"zone": "b"}
+	ms.Default()
+	if ms.Spec.Storage != nil || ms.Spec.NodeSelector["pool"] != "" {
+		t.Fatalf("expected admission to leave the class defaults out of the spec, got %+v and %v", ms.Spec.Storage, ms.Spec.NodeSelector)
+	}
+	ms.ApplyClassDefaults(class)
+	if ms.Spec.Storage == nil || ms.Spec.Storage.Size != "20Gi" {
+		t.Fatalf("expected the class storage, got %+v", ms.Spec.Storage)
+	}
+	if ms.Spec.NodeSelector["pool"] != "inference" || ms.Spec.NodeSelector["zone"] != "b" {
+		t.Fatalf("expected the model zone over the class node selector, got %v", ms.Spec.NodeSelector)
+	}
+
+	own := newTestModelServe()
+	own.Spec.ClassName = "standard"
+	own.Spec.Storage = &StorageSpec{EphemeralSizeGi: 10}
+	own.ApplyClassDefaults(class)
+	if own.Spec.Storage.Size != "" || own.Spec.Storage.EphemeralSizeGi != 10 {
+		t.Fatalf("expected the model storage to win, got %+v", own.Spec.Storage)
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(ms *ModelServe)
+		wantErr string
+	}{
+		{name: "within the class", mutate: func(*ModelServe) {}},
+		{name: "image not allowed", mutate: func(ms *ModelServe) { ms.Spec.Image = "example.com/server:1" }, wantErr: "not allowed by ModelServeClass standard"},
+		{name: "memory above the cap", mutate: func(ms *ModelServe) { ms.Spec.MemoryLimit = 16384 }, wantErr: "memoryLimit 16384 exceeds 8192"},
+		{
+			name: "replica group above the cap",
+			mutate: func(ms *ModelServe) {
+				ms.Spec.ReplicaGroups = []ReplicaGroup{{Name: "big", Replicas: 1, GPUCount: 2}}
+			},
+			wantErr: "replicaGroups: big gpuCount 2 exceeds 1",
+		},
+		{name: "missing class", mutate: func(ms *ModelServe) { ms.Spec.ClassName = "premium" }, wantErr: "ModelServeClass premium not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := newTestModelServe()
+			ms.Spec.ClassName = "standard"
+			ms.Default()
+			tt.mutate(ms)
+			_, err := ms.validateCreate(context.Background())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateTenantRoute(t *testing.T) {
+	s := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	if err := AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+
+	tenantNamespace := func(name, tenant string) *corev1.Namespace {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if tenant != "" {
+			ns.Labels = map[string]string{TenantLabel: tenant}
+		}
+		return ns
+	}
+	existing := newTestModelServe()
+	existing.Name = "chat"
+	existing.Namespace = "acme"
+	existing.Spec.RoutePath = "/acme/chat"
+
+	webhookClient = fake.NewClientBuilder().
+		WithScheme(s).
+		WithObjects(tenantNamespace("acme", "acme"), tenantNamespace("globex", "globex"), tenantNamespace("default", ""), existing).
+		Build()
+	t.Cleanup(func() { webhookClient = nil })
+
+	tests := []struct {
+		name      string
+		namespace string
+		msName    string
+		routePath string
+		wantErr   string
+	}{
+		{name: "route under own tenant", namespace: "globex", msName: "chat", routePath: "/globex/chat"},
+		{name: "second route of the same tenant", namespace: "acme", msName: "embed", routePath: "/acme/embed"},
+		{
+			name:      "route under another tenant",
+			namespace: "globex",
+			msName:    "chat",
+			routePath: "/acme/chat",
+			wantErr:   "outside the prefix of tenant globex",
+		},
+		{
+			name:      "untenanted route shadowing a tenant",
+			namespace: "default",
+			msName:    "acme",
+			wantErr:   "collides with /acme/chat of ModelServe acme/chat",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := newTestModelServe()
+			ms.Name = tt.msName
+			ms.Namespace = tt.namespace
+			ms.Spec.RoutePath = tt.routePath
+
+			_, err := ms.validateCreate(context.Background())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestDefaultProfiles(t *testing.T) {
+	zero := int32(0)
+	tests := []struct {
+		name        string
+		profile     string
+		memory      int32
+		gpuCount    *int32
+		contextSize *int32
+		want        ResourceProfile
+	}{
+		{name: "small", profile: "small", want: ResourceProfile{MemoryLimit: 2048, CPULimit: 1000, ContextSize: 2048}},
+		{name: "medium", profile: "medium", want: ResourceProfile{MemoryLimit: 8192, CPULimit: 4000, ContextSize: 4096}},
+		{name: "large", profile: "large", want: ResourceProfile{MemoryLimit: 16384, CPULimit: 8000, GPUCount: 1, ContextSize: 8192}},
+		{
+			name:    "explicit memory wins",
+			profile: "large",
+			memory:  12288,
+			want:    ResourceProfile{MemoryLimit: 12288, CPULimit: 8000, GPUCount: 1, ContextSize: 8192},
+		},
+		{
+			name:        "explicit zero wins",
+			profile:     "large",
+			gpuCount:    &zero,
+			contextSize: &zero,
+			want:        ResourceProfile{MemoryLimit: 16384, CPULimit: 8000},
+		},
+		{name: "no profile", want: ResourceProfile{MemoryLimit: 4096, CPULimit: 2000}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := newTestModelServe()
+			ms.Spec.Profile = tt.profile
+			ms.Spec.MemoryLimit = tt.memory
+			ms.Spec.GPUCount = tt.gpuCount
+			ms.Spec.ContextSize = tt.contextSize
+			ms.Default()
+
+			got := ResourceProfile{
+				MemoryLimit: ms.Spec.MemoryLimit,
+				CPULimit:    ms.Spec.CPULimit,
+				GPUCount:    ms.GPUs(),
+			}
+			if ms.Spec.ContextSize != nil {
+				got.ContextSize = *ms.Spec.ContextSize
+			}
+			if got != tt.want {
+				t.Fatalf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDefaultProfileFromConfigMap(t *testing.T) {
+	s := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	profiles := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: ProfilesConfigMap, Namespace: "default"},
+		Data:       map[string]string{"small": `{"memoryLimit": 3072, "contextSize": 1024}`},
+	}
+	webhookClient = fake.NewClientBuilder().WithScheme(s).WithObjects(profiles).Build()
+	t.Cleanup(func() { webhookClient = nil })
+
+	ms := newTestModelServe()
+	ms.Spec.Profile = "small"
+	ms.Default()
+
+	// Fields missing from the ConfigMap keep the built-in value
+	if ms.Spec.MemoryLimit != 3072 || ms.Spec.ContextSize == nil || *ms.Spec.ContextSize != 1024 || ms.Spec.CPULimit != 1000 {
+		t.Fatalf("expected ConfigMap profile over built-in defaults, got memory %d, context %v, cpu %d",
+			ms.Spec.MemoryLimit, ms.Spec.ContextSize, ms.Spec.CPULimit)
+	}
+}
+
+func TestPoliciesFromConfigMapRejectNonCompliantModel(t *testing.T) {
+	s := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	if err := AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	policies := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: PoliciesConfigMap, Namespace: "default"},
+		Data: map[string]string{
+			"cost-center":   `{"path": "/metadata/labels/example.com~1cost-center", "required": true, "message": "every model must carry a cost center"}`,
+			"storage-class": `{"path": "/spec/storage/storageClassName", "enum": ["fast", "standard"]}`,
+		},
+	}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(policies, namespace).Build()
+	webhookClient = c
+	t.Cleanup(func() { webhookClient = nil })
+
+	slow, fast := "slow", "fast"
+	ms := newTestModelServe()
+	ms.Spec.Storage = &StorageSpec{Size: "20Gi", StorageClassName: &slow}
+	_, err := ms.validateCreate(context.Background())
+	if err == nil {
+		t.Fatal("expected the non-compliant model to be rejected")
+	}
+	for _, want := range []string{"every model must carry a cost center", `must be one of fast, standard, got "slow"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q in the violations, got %v", want, err)
+		}
+	}
+
+	ms.Labels = map[string]string{"example.com/cost-center": "ml-platform"}
+	ms.Spec.Storage.StorageClassName = &fast
+	if _, err := ms.validateCreate(context.Background()); err != nil {
+		t.Fatalf("expected the compliant model to be admitted, got %v", err)
+	}
+
+	// A broken policy rejects instead of letting models through
+	policies.Data["broken"] = `{"path": "spec.image"}`
+	if err := c.Update(context.Background(), policies); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ms.validateCreate(context.Background()); err == nil || !strings.Contains(err.Error(), "invalid ModelServe policy broken") {
+		t.Fatalf("expected the invalid policy to be reported, got %v", err)
+	}
+}
+
+func TestValidateGPUResource(t *testing.T) {
+	for name, wantErr := range map[string]bool{
+		"nvidia.com/gpu":         false,
+		"nvidia.com/gpu.shared":  false,
+		"nvidia.com/mig-1g.5gb":  false,
+		"nvidia.com/mig-3g.20gb": false,
+		"amd.com/gpu":            true,
+		"nvidia.com/mig-9g.5gb":  true,
+	} {
+		ms := newTestModelServe()
+		gpuCount := int32(1)
+		ms.Spec.GPUCount = &gpuCount
+		ms.Spec.GPUResourceName = name
+		if _, err := ms.validateCreate(context.Background()); (err != nil) != wantErr {
+			t.Errorf("gpuResourceName %q: expected error %v, got %v", name, wantErr, err)
+		}
+	}
+}
+
+func TestValidateExtraPorts(t *testing.T) {
+	tests := []struct {
+		name    string
+		ports   []corev1.ContainerPort
+		wantErr string
+	}{
+		{name: "grpc and admin", ports: []corev1.ContainerPort{{Name: "grpc", ContainerPort: 50051}, {Name: "admin", ContainerPort: 8081}}},
+		{name: "main port", ports: []corev1.ContainerPort{{Name: "alt", ContainerPort: 8080}}, wantErr: "collides with port http"},
+		{name: "reserved name", ports: []corev1.ContainerPort{{Name: "http", ContainerPort: 8081}}, wantErr: `"http" is already in use`},
+		{
+			name:    "duplicate number",
+			ports:   []corev1.ContainerPort{{Name: "a", ContainerPort: 7000}, {Name: "b", ContainerPort: 7000}},
+			wantErr: "collides with port a",
+		},
+		{name: "unnamed", ports: []corev1.ContainerPort{{ContainerPort: 7000}}, wantErr: "needs a name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := newTestModelServe()
+			ms.Spec.ExtraPorts = tt.ports
+
+			_, err := ms.validateCreate(context.Background())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateGPUSharingNeedsShareableResource(t *testing.T) {
+	ms := newTestModelServe()
+	gpuCount := int32(1)
+	ms.Spec.GPUCount = &gpuCount
+	ms.Spec.GPUSharing = &GPUSharingSpec{Group: "small-models"}
+	if _, err := ms.validateCreate(context.Background()); err == nil || !strings.Contains(err.Error(), "shareable gpuResourceName") {
+		t.Fatalf("expected whole GPUs to be rejected for sharing, got %v", err)
+	}
+
+	ms.Spec.GPUResourceName = "nvidia.com/mig-1g.5gb"
+	if _, err := ms.validateCreate(context.Background()); err != nil {
+		t.Fatalf("unexpected error for a shared MIG slice: %v", err)
+	}
+}
+
+func TestValidateVerifyOnStart(t *testing.T) {
+	ms := newTestModelServe()
+	ms.Spec.Download = &DownloadSpec{SHA256: strings.Repeat("ab", 32), VerifyOnStart: true}
+	if _, err := ms.validateCreate(context.Background()); err == nil || !strings.Contains(err.Error(), "requires storage.size") {
+		t.Fatalf("expected verifyOnStart without a persistent volume to be rejected, got %v", err)
+	}
+
+	ms.Spec.Storage = &StorageSpec{Size: "20Gi"}
+	if _, err := ms.validateCreate(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestValidateChecksumObjectExcludesSHA256(t *testing.T) {
+	ms := newTestModelServe()
+	ms.Spec.Download = &DownloadSpec{ChecksumObject: "models/test.gguf.sha256"}
+	if _, err := ms.validateCreate(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ms.Spec.Download.SHA256 = strings.Repeat("ab", 32)
+	if _, err := ms.validateCreate(context.Background()); err == nil || !strings.Contains(err.Error(), "mutually exclusive") {
+		t.Fatalf("expected sha256 with checksumObject to be rejected, got %v", err)
+	}
+}
+
+func TestValidateDownloadProxy(t *testing.T) {
+	tests := []struct {
+		name     string
+		download DownloadSpec
+		wantErr  string
+	}{
+		{name: "proxies", download: DownloadSpec{HTTPProxy: "http://proxy:3128", HTTPSProxy: "https://proxy:3129", NoProxy: "minio, .cluster.local,10.0.0.0/8"}},
+		{name: "missing scheme", download: DownloadSpec{HTTPSProxy: "proxy:3128"}, wantErr: "download.httpsProxy"},
+		{name: "unsupported scheme", download: DownloadSpec{HTTPProxy: "socks5://proxy:1080"}, wantErr: "download.httpProxy"},
+		{name: "empty noProxy entry", download: DownloadSpec{NoProxy: "minio,,db"}, wantErr: "invalid entry"},
+		{name: "invalid CIDR", download: DownloadSpec{NoProxy: "10.0.0.0/33"}, wantErr: "invalid CIDR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := newTestModelServe()
+			ms.Spec.Download = &tt.download
+			_, err := ms.validateCreate(context.Background())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateDownloadCommand(t *testing.T) {
+	ms := newTestModelServe()
+	ms.Spec.Download = &DownloadSpec{Command: "curl -fo {{.Dest}} https://{{.Endpoint}}/{{.Path}}"}
+	if _, err := ms.validateCreate(context.Background()); err == nil || !strings.Contains(err.Error(), "disabled on this cluster") {
+		t.Fatalf("expected download.command to be rejected without %s, got %v", CustomDownloadCommandEnv, err)
+	}
+
+	t.Setenv(CustomDownloadCommandEnv, "true")
+	if _, err := ms.validateCreate(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for command, want := range map[string]string{
+		"curl -fo /tmp/model https://{{.Endpoint}}/{{.Path}}": "must write the model to {{.Dest}}",
+		"curl -fo {{.Dest}} {{.Token}}":                       "not a valid template",
+		"curl -fo {{.Dest}":                                   "not a valid template",
+	} {
+		ms.Spec.Download.Command = command
+		if _, err := ms.validateCreate(context.Background()); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q to be rejected with %q, got %v", command, want, err)
+		}
+	}
+}
+
+func TestDefaultRecordsTokenSubject(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	now := time.Now().Unix()
+	token := signTestJWT(t, "test-secret", map[string]interface{}{"sub": "alice", "type": "user", "exp": now + 3600, "iat": now})
+
+	d := &modelServeDefaulter{}
+	admissionContext := func(user string, old *ModelServe) context.Context {
+		req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			UserInfo: authenticationv1.UserInfo{Username: user},
+		}}
+		if old != nil {
+			raw, err := json.Marshal(old)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.OldObject.Raw = raw
+		}
+		return admission.NewContextWithRequest(context.Background(), req)
+	}
+
+	ms := newTestModelServe()
+	ms.Annotations = map[string]string{
+		authTokenAnnotation:      token,
+		LastModifiedByAnnotation: "mallory",
+	}
+	if err := d.Default(admissionContext("system:serviceaccount:apps:frontend", nil), ms); err != nil {
+		t.Fatal(err)
+	}
+	if got := ms.Annotations[LastModifiedByAnnotation]; got != "alice" {
+		t.Fatalf("expected the token subject to be recorded, got %q", got)
+	}
+
+	// A later update keeping the token is attributed to whoever sent it
+	old := ms.DeepCopy()
+	ms.Spec.MemoryLimit = 8192
+	if err := d.Default(admissionContext("bob", old), ms); err != nil {
+		t.Fatal(err)
+	}
+	if got := ms.Annotations[LastModifiedByAnnotation]; got != "bob" {
+		t.Fatalf("expected the requesting user for an unchanged token, got %q", got)
+	}
+
+	// A self-set value is replaced, and dropped when nobody can be named
+	delete(ms.Annotations, authTokenAnnotation)
+	ms.Annotations[LastModifiedByAnnotation] = "mallory"
+	if err := d.Default(context.Background(), ms); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := ms.Annotations[LastModifiedByAnnotation]; ok {
+		t.Fatalf("expected no attribution without a request, got %q", got)
+	}
+}
+
+// signTestJWT returns an HS256 token for the claims signed with secret
+func signTestJWT(t *testing.T, secret string, claims map[string]interface{}) string {
+	t.Helper()
+
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signingInput := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(signingInput))
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+func TestValidateJWTRequiredClaims(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	now := time.Now().Unix()
+	valid := func() map[string]interface{} {
+		return map[string]interface{}{"sub": "alice", "type": "user", "exp": now + 3600, "iat": now}
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(claims map[string]interface{})
+		wantErr string
+	}{
+		{name: "all claims present", mutate: func(map[string]interface{}) {}},
+		{name: "missing sub", mutate: func(c map[string]interface{}) { delete(c, "sub") }, wantErr: `missing required claim "sub"`},
+		{name: "missing type", mutate: func(c map[string]interface{}) { delete(c, "type") }, wantErr: `missing required claim "type"`},
+		{name: "missing exp", mutate: func(c map[string]interface{}) { delete(c, "exp") }, wantErr: `missing required claim "exp"`},
+		{name: "missing iat", mutate: func(c map[string]interface{}) { delete(c, "iat") }, wantErr: `missing required claim "iat"`},
+		{name: "null sub", mutate: func(c map[string]interface{}) { c["sub"] = nil }, wantErr: `missing required claim "sub"`},
+		{name: "empty sub", mutate: func(c map[string]interface{}) { c["sub"] = "" }, wantErr: "sub must not be empty"},
+		{name: "zero exp", mutate: func(c map[string]interface{}) { c["exp"] = 0 }, wantErr: "exp must be a positive expiry time"},
+		{name: "expired", mutate: func(c map[string]interface{}) { c["exp"] = now - 60 }, wantErr: "expired"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := valid()
+			tt.mutate(claims)
+			ms := newTestModelServe()
+			ms.Annotations = map[string]string{"model.example.com/auth-token": signTestJWT(t, "test-secret", claims)}
+
+			err := ms.validateJWT()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateJWTAllowedTypes(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	now := time.Now().Unix()
+
+	tests := []struct {
+		name      string
+		allowed   string
+		tokenType string
+		wantErr   bool
+	}{
+		{name: "default user", tokenType: "user"},
+		{name: "default internal", tokenType: "internal"},
+		{name: "default rejects service", tokenType: "service", wantErr: true},
+		{name: "custom service", allowed: "service, admin", tokenType: "service"},
+		{name: "custom admin", allowed: "service, admin", tokenType: "admin"},
+		{name: "custom rejects user", allowed: "service, admin", tokenType: "user", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("JWT_ALLOWED_TYPES", tt.allowed)
+			claims := map[string]interface{}{"sub": "alice", "type": tt.tokenType, "exp": now + 3600, "iat": now}
+			ms := newTestModelServe()
+			ms.Annotations = map[string]string{"model.example.com/auth-token": signTestJWT(t, "test-secret", claims)}
+
+			err := ms.validateJWT()
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "invalid token type") {
+					t.Fatalf("expected the token type to be rejected, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestDefaultMinIOPathFromModelName(t *testing.T) {
+	tests := []struct {
+		name         string
+		minioPath    string
+		quantization string
+		want         string
+	}{
+		{name: "derived from modelName", want: "models/test.gguf"},
+		{name: "derived from quantization variant", quantization: "Q4_K_M", want: "models/test.Q4_K_M.gguf"},
+		{name: "explicit path kept", minioPath: "custom/weights.gguf", want: "custom/weights.gguf"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := newTestModelServe()
+			ms.Spec.MinIOPath = tt.minioPath
+			ms.Spec.Quantization = tt.quantization
+			ms.Default()
+
+			if ms.Spec.MinIOPath != tt.want {
+				t.Fatalf("expected minioPath %q, got %q", tt.want, ms.Spec.MinIOPath)
+			}
+			if _, err := ms.validateCreate(context.Background()); err != nil {
+				t.Fatalf("expected defaulted ModelServe to validate, got %v", err)
+			}
+		})
+	}
+
+	// A derived path follows a later quantization change, an explicit one stays
+	d := &modelServeDefaulter{}
+	update := func(old, ms *ModelServe) {
+		raw, err := json.Marshal(old)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx := admission.NewContextWithRequest(context.Background(), admission.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{OldObject: runtime.RawExtension{Raw: raw}},
+		})
+		if err := d.Default(ctx, ms); err != nil {
+			t.Fatal(err)
+		}
+	}
+	derived := newTestModelServe()
+	derived.Spec.MinIOPath = ""
+	derived.Default()
+	quantized := derived.DeepCopy()
+	quantized.Spec.Quantization = "Q8_0"
+	update(derived, quantized)
+	if quantized.Spec.MinIOPath != "models/test.Q8_0.gguf" {
+		t.Fatalf("expected the derived minioPath to follow the quantization, got %q", quantized.Spec.MinIOPath)
+	}
+
+	explicit := newTestModelServe()
+	explicit.Spec.MinIOPath = "custom/weights.gguf"
+	quantized = explicit.DeepCopy()
+	quantized.Spec.Quantization = "Q8_0"
+	update(explicit, quantized)
+	if quantized.Spec.MinIOPath != "custom/weights.gguf" {
+		t.Fatalf("expected the explicit minioPath to be kept, got %q", quantized.Spec.MinIOPath)
+	}
+}
+
+func TestDefaultCanonicalizesRuntimeParams(t *testing.T) {
+	inputs := []string{
+		"--threads 8 --temp -0.5 --no-mmap --lora a.bin --lora b.bin",
+		"--no-mmap  --lora a.bin --temp -0.5 --lora b.bin --threads 8",
+		"--lora a.bin --threads 8 --lora b.bin --temp -0.5 --no-mmap --threads 8",
+	}
+	want := "--lora a.bin --lora b.bin --no-mmap --temp -0.5 --threads 8"
+
+	for _, params := range inputs {
+		ms := newTestModelServe()
+		ms.Spec.RuntimeParams = params
+		ms.Default()
+		if ms.Spec.RuntimeParams != want {
+			t.Fatalf("expected %q to canonicalize to %q, got %q", params, want, ms.Spec.RuntimeParams)
+		}
+	}
+
+	// The last of conflicting values keeps winning
+	ms := newTestModelServe()
+	ms.Spec.RuntimeParams = "--batch-size=512 --threads 4 --threads 8 --threads 4"
+	ms.Default()
+	if want := "--batch-size=512 --threads 8 --threads 4"; ms.Spec.RuntimeParams != want {
+		t.Fatalf("expected %q, got %q", want, ms.Spec.RuntimeParams)
+	}
+
+	// Aliases sort with their long form, so the later spelling still wins
+	ms = newTestModelServe()
+	ms.Spec.RuntimeParams = "--ctx-size 8192 -ngl 99 -c 2048 --n-gpu-layers 20 -np=4"
+	ms.Default()
+	if want := "--ctx-size 8192 --ctx-size 2048 --n-gpu-layers 99 --n-gpu-layers 20 --parallel=4"; ms.Spec.RuntimeParams != want {
+		t.Fatalf("expected %q, got %q", want, ms.Spec.RuntimeParams)
+	}
+}
+
+func TestMemoryLimitBelowUsageWarns(t *testing.T) {
+	old := newTestModelServe()
+	old.Spec.MemoryLimit = 8192
+	old.Status.PeakMemoryMB = 6000
+
+	ms := old.DeepCopy()
+	ms.Spec.MemoryLimit = 4096
+	warnings, err := ms.validateUpdate(context.Background(), old)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "observed using") {
+		t.Fatalf("expected a warning about the reported usage, got %v", warnings)
+	}
+
+	// Staying above the usage is fine
+	ms.Spec.MemoryLimit = 6144
+	if warnings, _ := ms.validateUpdate(context.Background(), old); len(warnings) != 0 {
+		t.Fatalf("expected no warnings, got %v", warnings)
+	}
+}
+
+func TestDryRunSkipsMinIOReachability(t *testing.T) {
+	var dialed []string
+	dialMinIO = func(_ context.Context, address string) error {
+		dialed = append(dialed, address)
+		return fmt.Errorf("connection refused")
+	}
+	t.Cleanup(func() { dialMinIO = defaultDialMinIO })
+
+	admissionContext := func(dryRun bool) context.Context {
+		return admission.NewContextWithRequest(context.Background(), admission.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{DryRun: &dryRun},
+		})
+	}
+	ms := newTestModelServe()
+	ms.Spec.MinIOEndpoint = "minio.unreachable:9000"
+	v := &modelServeValidator{}
+
+	warnings, err := v.ValidateCreate(admissionContext(true), ms)
+	if err != nil || len(warnings) != 0 {
+		t.Fatalf("expected dry-run to pass without warnings, got %v, %v", warnings, err)
+	}
+	if len(dialed) != 0 {
+		t.Fatalf("expected dry-run to skip the reachability check, dialed %v", dialed)
+	}
+
+	// Field validation still runs on dry-run
+	invalid := ms.DeepCopy()
+	invalid.Spec.ModelUUID = ""
+	if _, err := v.ValidateCreate(admissionContext(true), invalid); err == nil {
+		t.Fatal("expected dry-run to keep rejecting invalid fields")
+	}
+
+	warnings, err = v.ValidateCreate(admissionContext(false), ms)
+	if err != nil {
+		t.Fatalf("expected unreachable MinIO to only warn, got %v", err)
+	}
+	if len(dialed) != 1 || len(warnings) != 1 || !strings.Contains(warnings[0], "minio.unreachable:9000") {
+		t.Fatalf("expected one reachability warning, got %v (dialed %v)", warnings, dialed)
+	}
+
+	// Checks against other objects still run, but for the Deployment names
+	s := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	if err := AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	webhookClient = fake.NewClientBuilder().WithScheme(s).Build()
+	t.Cleanup(func() { webhookClient = nil })
+	classed := ms.DeepCopy()
+	classed.Spec.ClassName = "missing"
+	for _, dryRun := range []bool{true, false} {
+		if _, err := v.ValidateCreate(admissionContext(dryRun), classed); err == nil || !strings.Contains(err.Error(), "ModelServeClass missing not found") {
+			t.Fatalf("expected the class lookup with dry-run %v, got %v", dryRun, err)
+		}
+	}
+}
+
+func TestValidateBackends(t *testing.T) {
+	tests := []struct {
+		name     string
+		backends []ModelServeRef
+		wantErr  string
+	}{
+		{name: "weighted backends", backends: []ModelServeRef{{Name: "a", Weight: 3}, {Name: "b", Weight: 1}}},
+		{name: "drained backend", backends: []ModelServeRef{{Name: "a", Weight: 1}, {Name: "b"}}},
+		{name: "invalid name", backends: []ModelServeRef{{Name: "Not_Valid", Weight: 1}}, wantErr: "invalid ModelServe name"},
+		{name: "duplicate", backends: []ModelServeRef{{Name: "a", Weight: 1}, {Name: "a", Weight: 1}}, wantErr: "more than once"},
+		{name: "negative weight", backends: []ModelServeRef{{Name: "a", Weight: -1}}, wantErr: "negative weight"},
+		{name: "no traffic", backends: []ModelServeRef{{Name: "a"}, {Name: "b"}}, wantErr: "positive weight"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := newTestModelServe()
+			ms.Spec.Backends = tt.backends
+			_, err := ms.validateCreate(context.Background())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateParallelSlots(t *testing.T) {
+	slots := func(n int32) *int32 { return &n }
+
+	ms := newTestModelServe()
+	ms.Spec.ParallelSlots = slots(0)
+	if _, err := ms.validateCreate(context.Background()); err == nil || !strings.Contains(err.Error(), "parallelSlots must be positive") {
+		t.Fatalf("expected zero slots to be rejected, got %v", err)
+	}
+
+	ms.Spec.MemoryLimit = 4096
+	ms.Spec.ParallelSlots = slots(4)
+	warnings, err := ms.validateCreate(context.Background())
+	if err != nil || len(warnings) != 0 {
+		t.Fatalf("expected no warning for 4 slots, got %v, %v", warnings, err)
+	}
+
+	ms.Spec.ParallelSlots = slots(32)
+	warnings, err = ms.validateCreate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "parallelSlots 32 is high for memoryLimit 4096MB") {
+		t.Fatalf("expected a memory warning, got %v", warnings)
+	}
+}
+
+func TestValidateReplicaGroups(t *testing.T) {
+	tests := []struct {
+		name    string
+		groups  []ReplicaGroup
+		wantErr string
+	}{
+		{name: "distinct groups", groups: []ReplicaGroup{{Name: "small", Replicas: 2}, {Name: "large", Replicas: 1, MemoryLimit: 16384}}},
+		{name: "invalid name", groups: []ReplicaGroup{{Name: "Not_Valid", Replicas: 1}}, wantErr: "invalid name"},
+		{name: "duplicate", groups: []ReplicaGroup{{Name: "a", Replicas: 1}, {Name: "a", Replicas: 1}}, wantErr: "more than once"},
+		{name: "too many replicas", groups: []ReplicaGroup{{Name: "a", Replicas: 6}}, wantErr: "between 0 and 5"},
+		{name: "negative memory", groups: []ReplicaGroup{{Name: "a", Replicas: 1, MemoryLimit: -1}}, wantErr: "negative resource"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := newTestModelServe()
+			ms.Spec.ReplicaGroups = tt.groups
+			_, err := ms.validateCreate(context.Background())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateReplicaGroupDeploymentNames(t *testing.T) {
+	s := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	if err := AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	existing := newTestModelServe()
+	existing.Name = "llama-small"
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}
+	webhookClient = fake.NewClientBuilder().WithScheme(s).WithObjects(existing, namespace).Build()
+	t.Cleanup(func() { webhookClient = nil })
+
+	ms := newTestModelServe()
+	ms.Name = "llama"
+	ms.Spec.RoutePath = "/llama"
+	ms.Spec.ReplicaGroups = []ReplicaGroup{{Name: "small", Replicas: 1}}
+	if _, err := ms.validateCreate(context.Background()); err == nil || !strings.Contains(err.Error(), "llama-small is also used by ModelServe llama-small") {
+		t.Fatalf("expected the group Deployment name to collide, got %v", err)
+	}
+
+	ms.Spec.ReplicaGroups = []ReplicaGroup{{Name: "large", Replicas: 1}}
+	if _, err := ms.validateCreate(context.Background()); err != nil {
+		t.Fatalf("expected a distinct group name to pass, got %v", err)
+	}
+}
+
+func TestValidateStartupScript(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		wantErr bool
+	}{
+		{name: "prepares the volume", script: "mkdir -p /models/cache\necho ready"},
+		{name: "mentions the server", script: "# llama-server reads /models/cache\necho configuring llama-server"},
+		{name: "execs the server", script: "echo starting\nexec llama-server --port 8080", wantErr: true},
+		{name: "runs the server by path", script: "  /app/llama-server -m /models/model.gguf &", wantErr: true},
+		{name: "runs the server in the background", script: "nohup llama-server &", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := newTestModelServe()
+			ms.Spec.StartupScript = tt.script
+			if err := ms.validateStartupScript(); (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestActivationSkipsTokenChecks(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	past := time.Now().Add(-time.Hour).Unix()
+	expired := signTestJWT(t, "test-secret", map[string]interface{}{"sub": "alice", "type": "user", "exp": past, "iat": past - 3600})
+
+	activator := "system:serviceaccount:default:" + ActivatorServiceAccountName("test")
+	admissionContext := func(user string) context.Context {
+		return admission.NewContextWithRequest(context.Background(), admission.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{UserInfo: authenticationv1.UserInfo{Username: user}},
+		})
+	}
+	old := newTestModelServe()
+	old.Spec.ModelDownloadMode = ModelDownloadModeLazy
+	old.Annotations = map[string]string{authTokenAnnotation: expired}
+	v := &modelServeValidator{}
+
+	// The activator asking for the model gets through the expired token
+	activated := old.DeepCopy()
+	activated.Annotations[ActivationRequestedAnnotation] = "2024-01-01T00:00:00Z"
+	activated.Annotations[LastModifiedByAnnotation] = activator
+	if _, err := v.ValidateUpdate(admissionContext(activator), old, activated); err != nil {
+		t.Fatalf("expected the activation to pass, got %v", err)
+	}
+
+	// Any other change still needs a valid token
+	changed := activated.DeepCopy()
+	changed.Spec.Image = "attacker/llama:latest"
+	if _, err := v.ValidateUpdate(admissionContext("bob"), old, changed); err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Fatalf("expected the expired token to reject a spec change, got %v", err)
+	}
+
+	// and the activator may not make it even with one
+	now := time.Now().Unix()
+	changed.Annotations[authTokenAnnotation] = signTestJWT(t, "test-secret", map[string]interface{}{"sub": "alice", "type": "user", "exp": now + 3600, "iat": now})
+	if _, err := v.ValidateUpdate(admissionContext(activator), old, changed); err == nil || !strings.Contains(err.Error(), "activator") {
+		t.Fatalf("expected the activator to be limited to the activation, got %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ModelServeClassSpec defines the policies of a ModelServeClass. Defaults
+// only fill fields a ModelServe leaves unset; the admission webhook rejects
+// ModelServes breaking the limits.
+type ModelServeClassSpec struct {
+	// AllowedImages restricts the server image of the models. An entry ending
+	// in * matches every image with that prefix. Empty allows any image.
+	// +optional
+	AllowedImages []string `json:"allowedImages,omitempty"`
+
+	// MaxMemoryLimit is the largest memoryLimit in MB a model may request
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxMemoryLimit int32 `json:"maxMemoryLimit,omitempty"`
+
+	// MaxCPULimit is the largest cpuLimit in millicores a model may request
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxCPULimit int32 `json:"maxCpuLimit,omitempty"`
+
+	// MaxGPUCount is the largest gpuCount a model may request
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxGPUCount int32 `json:"maxGpuCount,omitempty"`
+
+	// Storage is the model volume of models without spec.storage
+	// +optional
+	Storage *StorageSpec `json:"storage,omitempty"`
+
+	// NodeSelector is merged under spec.nodeSelector of the models. Keys set
+	// on the model win.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster
+
+// ModelServeClass is the Schema for the modelserveclasses API. It carries the
+// policies of the ModelServes selecting it with spec.className.
+type ModelServeClass struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ModelServeClassSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ModelServeClassList contains a list of ModelServeClass
+type ModelServeClassList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ModelServeClass `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ModelServeClass{}, &ModelServeClassList{})
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"github.com/example/model-operator/api/v1alpha1"
+)
+
+// defaultGPUResource is the GPU resource of v1alpha1 models without
+// gpuResourceName
+const defaultGPUResource corev1.ResourceName = "nvidia.com/gpu"
+
+// ConvertTo converts this ModelServe to the v1alpha1 hub. Specs v1alpha1 cannot
+// express, such as several models or resource requests, are rejected.
+func (src *ModelServe) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1alpha1.ModelServe)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Status = src.Status
+
+	if len(src.Spec.Models) != 1 {
+		return fmt.Errorf("v1alpha1 serves exactly one model, got %d", len(src.Spec.Models))
+	}
+	model := src.Spec.Models[0]
+	in := &src.Spec
+	out := &dst.Spec
+	out.ModelName = model.Name
+	out.ModelUUID = model.UUID
+	out.MinIOPath = model.Path
+	out.MinIOEndpoint = model.Endpoint
+	out.MinIOBucket = model.Bucket
+	out.Quantization = model.Quantization
+	out.Download = model.Download
+
+	if len(in.Resources.Requests) > 0 || len(in.Resources.Claims) > 0 {
+		return fmt.Errorf("v1alpha1 only supports resource limits")
+	}
+	for name, quantity := range in.Resources.Limits {
+		switch name {
+		case corev1.ResourceMemory:
+			out.MemoryLimit = int32(quantity.Value() / (1024 * 1024))
+		case corev1.ResourceCPU:
+			out.CPULimit = int32(quantity.MilliValue())
+		default:
+			if out.GPUResourceName != "" || out.GPUCount != nil {
+				return fmt.Errorf("v1alpha1 supports a single GPU resource, got another %s", name)
+			}
+			count := int32(quantity.Value())
+			out.GPUCount = &count
+			// The default resource is implied
+			if name != defaultGPUResource {
+				out.GPUResourceName = string(name)
+			}
+		}
+	}
+
+	out.Image = in.Image
+	out.Replicas = in.Replicas
+	out.WarmPool = in.WarmPool
+	out.RuntimeParams = in.RuntimeParams
+	out.ContextSize = in.ContextSize
+	out.ParallelSlots = in.ParallelSlots
+	out.ProgressDeadlineSeconds = in.ProgressDeadlineSeconds
+	out.MinReadySeconds = in.MinReadySeconds
+	out.AutomountServiceAccountToken = in.AutomountServiceAccountToken
+	out.WarmupBackend = in.WarmupBackend
+	out.RetainOnFailure = in.RetainOnFailure
+	out.ModelReadOnly = in.ModelReadOnly
+	out.ReadOnlyRootFilesystem = in.ReadOnlyRootFilesystem
+	out.ExtraPorts = in.ExtraPorts
+	out.GPUSharing = in.GPUSharing
+	out.ClassName = in.ClassName
+	out.Profile = in.Profile
+	out.SecurityProfile = in.SecurityProfile
+	out.FSGroup = in.FSGroup
+	out.Backends = in.Backends
+	out.ReplicaGroups = in.ReplicaGroups
+	out.ConfigFile = in.ConfigFile
+	out.NetworkPolicy = in.NetworkPolicy
+	out.PodLabels = in.PodLabels
+	out.NodeSelector = in.NodeSelector
+	out.DeploymentAnnotations = in.DeploymentAnnotations
+	out.StartupScript = in.StartupScript
+	out.Monitoring = in.Monitoring
+	out.Storage = in.Storage
+	out.EvictionPolicy = in.EvictionPolicy
+	out.ModelDownloadMode = in.ModelDownloadMode
+	out.RoutePath = in.RoutePath
+	out.RouteWhenReady = in.RouteWhenReady
+	out.Public = in.Public
+	out.HeadlessService = in.HeadlessService
+	out.Preflight = in.Preflight
+	out.HealthCheck = in.HealthCheck
+	out.CompletionProbe = in.CompletionProbe
+	return nil
+}
+
+// ConvertFrom converts the v1alpha1 hub to this version: the model moves to
+// models and the memory, CPU and GPU limits to resources.limits
+func (dst *ModelServe) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1alpha1.ModelServe)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Status = src.Status
+
+	in := &src.Spec
+	out := &dst.Spec
+	out.Models = []ModelSource{{
+		Name:         in.ModelName,
+		UUID:         in.ModelUUID,
+		Path:         in.MinIOPath,
+		Endpoint:     in.MinIOEndpoint,
+		Bucket:       in.MinIOBucket,
+		Quantization: in.Quantization,
+		Download:     in.Download,
+	}}
+
+	limits := corev1.ResourceList{}
+	if in.MemoryLimit != 0 {
+		limits[corev1.ResourceMemory] = resource.MustParse(fmt.Sprintf("%dMi", in.MemoryLimit))
+	}
+	if in.CPULimit != 0 {
+		limits[corev1.ResourceCPU] = *resource.NewMilliQuantity(int64(in.CPULimit), resource.DecimalSI)
+	}
+	if in.GPUCount != nil || in.GPUResourceName != "" {
+		gpu := defaultGPUResource
+		if in.GPUResourceName != "" {
+			gpu = corev1.ResourceName(in.GPUResourceName)
+		}
+		count := int32(0)
+		if in.GPUCount != nil {
+			count = *in.GPUCount
+		}
+		limits[gpu] = *resource.NewQuantity(int64(count), resource.DecimalSI)
+	}
+	out.Resources = corev1.ResourceRequirements{}
+	if len(limits) > 0 {
+		out.Resources.Limits = limits
+	}
+
+	out.Image = in.Image
+	out.Replicas = in.Replicas
+	out.WarmPool = in.WarmPool
+	out.RuntimeParams = in.RuntimeParams
+	out.ContextSize = in.ContextSize
+	out.ParallelSlots = in.ParallelSlots
+	out.ProgressDeadlineSeconds = in.ProgressDeadlineSeconds
+	out.MinReadySeconds = in.MinReadySeconds
+	out.AutomountServiceAccountToken = in.AutomountServiceAccountToken
+	out.WarmupBackend = in.WarmupBackend
+	out.RetainOnFailure = in.RetainOnFailure
+	out.ModelReadOnly = in.ModelReadOnly
+	out.ReadOnlyRootFilesystem = in.ReadOnlyRootFilesystem
+	out.ExtraPorts = in.ExtraPorts
+	out.GPUSharing = in.GPUSharing
+	out.ClassName = in.ClassName
+	out.Profile = in.Profile
+	out.SecurityProfile = in.SecurityProfile
+	out.FSGroup = in.FSGroup
+	out.Backends = in.Backends
+	out.ReplicaGroups = in.ReplicaGroups
+	out.ConfigFile = in.ConfigFile
+	out.NetworkPolicy = in.NetworkPolicy
+	out.PodLabels = in.PodLabels
+	out.NodeSelector = in.NodeSelector
+	out.DeploymentAnnotations = in.DeploymentAnnotations
+	out.StartupScript = in.StartupScript
+	out.Monitoring = in.Monitoring
+	out.Storage = in.Storage
+	out.EvictionPolicy = in.EvictionPolicy
+	out.ModelDownloadMode = in.ModelDownloadMode
+	out.RoutePath = in.RoutePath
+	out.RouteWhenReady = in.RouteWhenReady
+	out.Public = in.Public
+	out.HeadlessService = in.HeadlessService
+	out.Preflight = in.Preflight
+	out.HealthCheck = in.HealthCheck
+	out.CompletionProbe = in.CompletionProbe
+	return nil
+}
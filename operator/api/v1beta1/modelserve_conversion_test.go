@@ -0,0 +1,153 @@
+package v1beta1
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/example/model-operator/api/v1alpha1"
+)
+
+func representativeModelServe() *v1alpha1.ModelServe {
+	replicas := int32(2)
+	slots := int32(4)
+	gpuCount := int32(1)
+	contextSize := int32(4096)
+	return &v1alpha1.ModelServe{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "llama",
+			Namespace:   "models",
+			Labels:      map[string]string{"team": "search"},
+			Annotations: map[string]string{v1alpha1.LastModifiedByAnnotation: "alice"},
+		},
+		Spec: v1alpha1.ModelServeSpec{
+			ModelName:       "llama.gguf",
+			ModelUUID:       "llama-uuid",
+			MinIOPath:       "models/llama.Q4_K_M.gguf",
+			MinIOEndpoint:   "minio:9000",
+			MinIOBucket:     "inference-models",
+			Quantization:    "Q4_K_M",
+			Download:        &v1alpha1.DownloadSpec{ChecksumObject: "models/llama.gguf.sha256", HTTPSProxy: "http://proxy:3128"},
+			Image:           "ghcr.io/ggerganov/llama.cpp:server",
+			Replicas:        &replicas,
+			RuntimeParams:   "--threads 8",
+			MemoryLimit:     8192,
+			CPULimit:        2500,
+			GPUCount:        &gpuCount,
+			GPUResourceName: "nvidia.com/mig-1g.5gb",
+			ContextSize:     &contextSize,
+			ParallelSlots:   &slots,
+			ClassName:       "standard",
+			Storage:         &v1alpha1.StorageSpec{Size: "20Gi"},
+			NodeSelector:    map[string]string{"pool": "gpu"},
+			ReplicaGroups:   []v1alpha1.ReplicaGroup{{Name: "large", Replicas: 1, MemoryLimit: 16384}},
+			Backends:        []v1alpha1.ModelServeRef{{Name: "llama", Weight: 1}},
+			HealthCheck:     &v1alpha1.HealthCheckSpec{LoadingEndpoint: "/health"},
+			RoutePath:       "/search/llama",
+			Public:          true,
+		},
+		Status: v1alpha1.ModelServeStatus{AvailableReplicas: 2, Phase: "Running"},
+	}
+}
+
+func TestConversionRoundTrip(t *testing.T) {
+	original := representativeModelServe()
+
+	beta := &ModelServe{}
+	if err := beta.ConvertFrom(original.DeepCopy()); err != nil {
+		t.Fatal(err)
+	}
+	if len(beta.Spec.Models) != 1 || beta.Spec.Models[0].Path != "models/llama.Q4_K_M.gguf" || beta.Spec.Models[0].Download == nil {
+		t.Fatalf("expected the model in models[0], got %+v", beta.Spec.Models)
+	}
+	limits := beta.Spec.Resources.Limits
+	for name, want := range map[corev1.ResourceName]string{
+		corev1.ResourceMemory:   "8Gi",
+		corev1.ResourceCPU:      "2500m",
+		"nvidia.com/mig-1g.5gb": "1",
+	} {
+		if got := limits[name]; got.Cmp(resource.MustParse(want)) != 0 {
+			t.Fatalf("expected %s limit %s, got %s", name, want, got.String())
+		}
+	}
+
+	back := &v1alpha1.ModelServe{}
+	if err := beta.ConvertTo(back); err != nil {
+		t.Fatal(err)
+	}
+	if !equality.Semantic.DeepEqual(back, original) {
+		t.Fatalf("round trip changed the ModelServe:\n got %+v\nwant %+v", back.Spec, original.Spec)
+	}
+}
+
+func TestConversionDefaultGPUResource(t *testing.T) {
+	original := representativeModelServe()
+	original.Spec.GPUResourceName = ""
+	gpuCount := int32(2)
+	original.Spec.GPUCount = &gpuCount
+
+	beta := &ModelServe{}
+	if err := beta.ConvertFrom(original.DeepCopy()); err != nil {
+		t.Fatal(err)
+	}
+	if got := beta.Spec.Resources.Limits[defaultGPUResource]; got.Value() != 2 {
+		t.Fatalf("expected 2 %s, got %s", defaultGPUResource, got.String())
+	}
+
+	back := &v1alpha1.ModelServe{}
+	if err := beta.ConvertTo(back); err != nil {
+		t.Fatal(err)
+	}
+	if back.Spec.GPUResourceName != "" || back.GPUs() != 2 {
+		t.Fatalf("expected the implied default GPU resource, got %q x%d", back.Spec.GPUResourceName, back.GPUs())
+	}
+}
+
+func TestConvertToRejectsWhatV1alpha1CannotExpress(t *testing.T) {
+	beta := &ModelServe{}
+	if err := beta.ConvertFrom(representativeModelServe()); err != nil {
+		t.Fatal(err)
+	}
+
+	several := beta.DeepCopy()
+	several.Spec.Models = append(several.Spec.Models, ModelSource{Name: "other.gguf"})
+	if err := several.ConvertTo(&v1alpha1.ModelServe{}); err == nil || !strings.Contains(err.Error(), "exactly one model") {
+		t.Fatalf("expected several models to be rejected, got %v", err)
+	}
+
+	requests := beta.DeepCopy()
+	requests.Spec.Resources.Requests = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")}
+	if err := requests.ConvertTo(&v1alpha1.ModelServe{}); err == nil || !strings.Contains(err.Error(), "only supports resource limits") {
+		t.Fatalf("expected resource requests to be rejected, got %v", err)
+	}
+}
+
+// TestSpecCoversV1alpha1 keeps new v1alpha1 fields from being dropped by the
+// conversion
+func TestSpecCoversV1alpha1(t *testing.T) {
+	moved := map[string]bool{
+		"modelName": true, "modelUuid": true, "minioPath": true, "minioEndpoint": true, "minioBucket": true,
+		"quantization": true, "download": true,
+		"memoryLimit": true, "cpuLimit": true, "gpuCount": true, "gpuResourceName": true,
+	}
+	jsonFields := func(typ reflect.Type) map[string]bool {
+		fields := map[string]bool{}
+		for i := 0; i < typ.NumField(); i++ {
+			name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+			fields[name] = true
+		}
+		return fields
+	}
+
+	beta := jsonFields(reflect.TypeOf(ModelServeSpec{}))
+	for name := range jsonFields(reflect.TypeOf(v1alpha1.ModelServeSpec{})) {
+		if !moved[name] && !beta[name] {
+			t.Errorf("v1alpha1 field %s has no v1beta1 counterpart", name)
+		}
+	}
+}
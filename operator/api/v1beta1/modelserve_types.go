@@ -0,0 +1,185 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/example/model-operator/api/v1alpha1"
+)
+
+// Option types that did not change between the versions are shared with
+// v1alpha1 until they diverge.
+type (
+	CompletionProbeSpec = v1alpha1.CompletionProbeSpec
+	ConfigFileSpec      = v1alpha1.ConfigFileSpec
+	DownloadSpec        = v1alpha1.DownloadSpec
+	GPUSharingSpec      = v1alpha1.GPUSharingSpec
+	HealthCheckSpec     = v1alpha1.HealthCheckSpec
+	ModelServeRef       = v1alpha1.ModelServeRef
+	ModelServeStatus    = v1alpha1.ModelServeStatus
+	MonitoringSpec      = v1alpha1.MonitoringSpec
+	NetworkPolicySpec   = v1alpha1.NetworkPolicySpec
+	ReplicaGroup        = v1alpha1.ReplicaGroup
+	StorageSpec         = v1alpha1.StorageSpec
+)
+
+// ModelSource locates a model file in MinIO
+type ModelSource struct {
+	// Name of the model file
+	Name string `json:"name"`
+
+	// UUID is the unique identifier of the model
+	UUID string `json:"uuid"`
+
+	// Path of the model object in the bucket
+	Path string `json:"path"`
+
+	// Endpoint of the MinIO service
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Bucket holding the model
+	// +optional
+	Bucket string `json:"bucket,omitempty"`
+
+	// Quantization selects the model variant <base>.<quantization>.gguf
+	// +optional
+	Quantization string `json:"quantization,omitempty"`
+
+	// Download configures how the model is downloaded and verified
+	// +optional
+	Download *DownloadSpec `json:"download,omitempty"`
+}
+
+// ModelServeSpec defines the desired state of ModelServe. Compared to
+// v1alpha1 the model location moves to models and the memory, CPU and GPU
+// limits to resources; the other fields keep their v1alpha1 meaning.
+type ModelServeSpec struct {
+	// Models served by the server. Only a single model is supported yet.
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=1
+	Models []ModelSource `json:"models"`
+
+	// Resources of the server container. The GPU is requested as its
+	// extended resource, e.g. nvidia.com/gpu.
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// +optional
+	Image string `json:"image,omitempty"`
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+	// +optional
+	WarmPool int32 `json:"warmPool,omitempty"`
+	// +optional
+	RuntimeParams string `json:"runtimeParams,omitempty"`
+	// +optional
+	ContextSize *int32 `json:"contextSize,omitempty"`
+	// +optional
+	ParallelSlots *int32 `json:"parallelSlots,omitempty"`
+	// +optional
+	ProgressDeadlineSeconds *int32 `json:"progressDeadlineSeconds,omitempty"`
+	// +optional
+	MinReadySeconds *int32 `json:"minReadySeconds,omitempty"`
+	// +optional
+	AutomountServiceAccountToken *bool `json:"automountServiceAccountToken,omitempty"`
+	// +optional
+	WarmupBackend bool `json:"warmupBackend,omitempty"`
+	// +optional
+	RetainOnFailure bool `json:"retainOnFailure,omitempty"`
+	// +optional
+	ModelReadOnly *bool `json:"modelReadOnly,omitempty"`
+	// +optional
+	ReadOnlyRootFilesystem bool `json:"readOnlyRootFilesystem,omitempty"`
+	// +optional
+	ExtraPorts []corev1.ContainerPort `json:"extraPorts,omitempty"`
+	// +optional
+	GPUSharing *GPUSharingSpec `json:"gpuSharing,omitempty"`
+	// +optional
+	ClassName string `json:"className,omitempty"`
+	// +optional
+	Profile string `json:"profile,omitempty"`
+	// +optional
+	SecurityProfile string `json:"securityProfile,omitempty"`
+	// +optional
+	FSGroup *int64 `json:"fsGroup,omitempty"`
+	// +optional
+	Backends []ModelServeRef `json:"backends,omitempty"`
+	// +optional
+	ReplicaGroups []ReplicaGroup `json:"replicaGroups,omitempty"`
+	// +optional
+	ConfigFile *ConfigFileSpec `json:"configFile,omitempty"`
+	// +optional
+	NetworkPolicy *NetworkPolicySpec `json:"networkPolicy,omitempty"`
+	// +optional
+	PodLabels map[string]string `json:"podLabels,omitempty"`
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// +optional
+	DeploymentAnnotations map[string]string `json:"deploymentAnnotations,omitempty"`
+	// +optional
+	StartupScript string `json:"startupScript,omitempty"`
+	// +optional
+	Monitoring *MonitoringSpec `json:"monitoring,omitempty"`
+	// +optional
+	Storage *StorageSpec `json:"storage,omitempty"`
+	// +optional
+	EvictionPolicy string `json:"evictionPolicy,omitempty"`
+	// +optional
+	ModelDownloadMode string `json:"modelDownloadMode,omitempty"`
+	// +optional
+	RoutePath string `json:"routePath,omitempty"`
+	// +optional
+	RouteWhenReady bool `json:"routeWhenReady,omitempty"`
+	// +optional
+	Public bool `json:"public,omitempty"`
+	// +optional
+	HeadlessService bool `json:"headlessService,omitempty"`
+	// +optional
+	Preflight bool `json:"preflight,omitempty"`
+	// +optional
+	HealthCheck *HealthCheckSpec `json:"healthCheck,omitempty"`
+	// +optional
+	CompletionProbe *CompletionProbeSpec `json:"completionProbe,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// ModelServe is the Schema for the modelserves API
+type ModelServe struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ModelServeSpec   `json:"spec,omitempty"`
+	Status ModelServeStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ModelServeList contains a list of ModelServe
+type ModelServeList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ModelServe `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ModelServe{}, &ModelServeList{})
+}
@@ -32,6 +32,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	modelv1alpha1 "github.com/example/model-operator/api/v1alpha1"
+	modelv1beta1 "github.com/example/model-operator/api/v1beta1"
 	"github.com/example/model-operator/internal/controller"
 	//+kubebuilder:scaffold:imports
 )
@@ -45,10 +46,16 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(modelv1alpha1.AddToScheme(scheme))
+	// Registering the spoke version serves the conversion webhook
+	utilruntime.Must(modelv1beta1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == migrateCommand {
+		os.Exit(runMigrate(os.Args[2:]))
+	}
+
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
@@ -90,8 +97,10 @@ func main() {
 	}
 
 	if err = (&controller.ModelServeReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:    mgr.GetClient(),
+		Scheme:    mgr.GetScheme(),
+		Recorder:  mgr.GetEventRecorderFor("modelserve-controller"),
+		APIReader: mgr.GetAPIReader(),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ModelServe")
 		os.Exit(1)
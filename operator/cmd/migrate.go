@@ -0,0 +1,59 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/example/model-operator/internal/migrate"
+)
+
+// migrateCommand prints the stored ModelServes as v1beta1 objects instead of
+// running the manager
+const migrateCommand = "migrate-v1beta1"
+
+// runMigrate runs the migrate-v1beta1 subcommand and returns its exit code
+func runMigrate(args []string) int {
+	fs := flag.NewFlagSet(migrateCommand, flag.ContinueOnError)
+	namespace := fs.String("namespace", "", "Namespace of the ModelServes to migrate (default all namespaces)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := ctrl.GetConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to load kubeconfig: %v\n", err)
+		return 1
+	}
+	c, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to create client: %v\n", err)
+		return 1
+	}
+
+	if err := migrate.ToV1beta1(context.Background(), c, *namespace, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	modelv1alpha1 "github.com/example/model-operator/api/v1alpha1"
+)
+
+// failureClassPolicy marks a model outside the limits of its ModelServeClass
+const failureClassPolicy = "ClassPolicyViolation"
+
+// applyClass fills the fields the model leaves unset from its ModelServeClass.
+// The defaults are applied in memory on every reconcile and never written to
+// the spec, so class edits reach the models selecting it. Fields set on the
+// model are kept. A missing class keeps the model Pending until the class is
+// created, and a model outside the image and resource limits of the class
+// fails until either is fixed, as the webhook is not always enabled. The
+// policy is checked against the image and limits actually deployed.
+func (r *ModelServeReconciler) applyClass(ctx context.Context, m *modelv1alpha1.ModelServe) (bool, ctrl.Result, error) {
+	if m.Spec.ClassName == "" {
+		return false, ctrl.Result{}, nil
+	}
+
+	class := &modelv1alpha1.ModelServeClass{}
+	err := r.Get(ctx, types.NamespacedName{Name: m.Spec.ClassName}, class)
+	if errors.IsNotFound(err) {
+		message := "Waiting for ModelServeClass " + m.Spec.ClassName
+		if m.Status.Phase != "Pending" || m.Status.Message != message {
+			m.Status.Phase = "Pending"
+			m.Status.Message = message
+			return true, ctrl.Result{}, r.updateStatus(ctx, m)
+		}
+		return true, ctrl.Result{}, nil
+	}
+	if err != nil {
+		return true, ctrl.Result{}, err
+	}
+
+	m.ApplyClassDefaults(class)
+
+	if err := effectiveModelServe(m).ValidateClassPolicy(class); err != nil {
+		problem := err.Error()
+		if m.Status.Phase != "Failed" || m.Status.Message != problem {
+			r.Recorder.Event(m, corev1.EventTypeWarning, failureClassPolicy, problem)
+			m.Status.Phase = "Failed"
+			m.Status.Message = problem
+			m.Status.FailureReason = failureClassPolicy
+			return true, ctrl.Result{}, r.updateStatus(ctx, m)
+		}
+		return true, ctrl.Result{}, nil
+	}
+	if m.Status.FailureReason == failureClassPolicy {
+		m.Status.Phase = "Pending"
+		m.Status.Message = "Model is within its ModelServeClass"
+		m.Status.FailureReason = ""
+		return false, ctrl.Result{}, r.updateStatus(ctx, m)
+	}
+	return false, ctrl.Result{}, nil
+}
+
+// effectiveModelServe returns a copy of m with the image and limits the
+// controller deploys, so the class policy holds for FORCE_IMAGE and the
+// defaults of unset fields as well. Replica groups without their own limits
+// inherit these.
+func effectiveModelServe(m *modelv1alpha1.ModelServe) *modelv1alpha1.ModelServe {
+	effective := m.DeepCopy()
+	effective.Spec.Image = serverImage(m)
+	effective.Spec.MemoryLimit = serverMemoryLimit(m)
+	effective.Spec.CPULimit = serverCPULimit(m)
+	return effective
+}
+
+// updateStatus writes the status of m. Only the metadata and status are sent,
+// so neither the response nor the update touches the class defaults applied to
+// the spec in memory. A failed model releases its rollout slot, as it will not
+// finish the rollout until it is fixed.
+func (r *ModelServeReconciler) updateStatus(ctx context.Context, m *modelv1alpha1.ModelServe) error {
+	if m.Status.Phase == "Failed" {
+		m.Status.RolloutInProgress = false
+	}
+	update := &modelv1alpha1.ModelServe{
+		ObjectMeta: *m.ObjectMeta.DeepCopy(),
+		Status:     *m.Status.DeepCopy(),
+	}
+	if err := r.Status().Update(ctx, update); err != nil {
+		return err
+	}
+	m.ObjectMeta = update.ObjectMeta
+	return nil
+}
+
+// modelServesForClass maps a ModelServeClass event to the ModelServes selecting it
+func (r *ModelServeReconciler) modelServesForClass(ctx context.Context, obj client.Object) []reconcile.Request {
+	modelServes := &modelv1alpha1.ModelServeList{}
+	if err := r.List(ctx, modelServes); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list ModelServes for ModelServeClass", "ModelServeClass", obj.GetName())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, ms := range modelServes.Items {
+		if ms.Spec.ClassName == obj.GetName() {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: ms.Name, Namespace: ms.Namespace},
+			})
+		}
+	}
+	return requests
+}
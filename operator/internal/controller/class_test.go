@@ -0,0 +1,153 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	modelv1alpha1 "github.com/example/model-operator/api/v1alpha1"
+)
+
+func TestModelServeClassDefaultsApply(t *testing.T) {
+	class := &modelv1alpha1.ModelServeClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "gpu"},
+		Spec: modelv1alpha1.ModelServeClassSpec{
+			Storage:      &modelv1alpha1.StorageSpec{Size: "50Gi"},
+			NodeSelector: map[string]string{"pool": "gpu", "zone": "a"},
+		},
+	}
+	ms := newTestModelServe("classed")
+	ms.Spec.ClassName = "gpu"
+	ms.Spec.NodeSelector = map[string]string{"zone": "b"}
+	r := newTestReconciler(t, class, ms)
+	reconcileUntilStable(t, r, "classed")
+
+	// The class fills the node selector, the model keeps its own zone
+	podSpec := getDeployment(t, r, "classed").Spec.Template.Spec
+	if podSpec.NodeSelector["pool"] != "gpu" || podSpec.NodeSelector["zone"] != "b" {
+		t.Fatalf("expected the class node selector under the model one, got %v", podSpec.NodeSelector)
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{}
+	if err := r.Get(context.Background(), types.NamespacedName{Name: modelClaimName(ms), Namespace: "default"}, pvc); err != nil {
+		t.Fatalf("expected the class storage to create the model claim: %v", err)
+	}
+	if size := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; size.String() != "50Gi" {
+		t.Fatalf("expected a 50Gi claim, got %s", size.String())
+	}
+
+	// The defaults stay out of the stored spec
+	stored := getModelServe(t, r, "classed")
+	if stored.Spec.Storage != nil || len(stored.Spec.NodeSelector) != 1 {
+		t.Fatalf("expected only the model fields in the spec, got storage %+v and node selector %v", stored.Spec.Storage, stored.Spec.NodeSelector)
+	}
+
+	// So an edit of the class reaches the model
+	if err := r.Get(context.Background(), types.NamespacedName{Name: "gpu"}, class); err != nil {
+		t.Fatal(err)
+	}
+	class.Spec.NodeSelector["pool"] = "a100"
+	if err := r.Update(context.Background(), class); err != nil {
+		t.Fatal(err)
+	}
+	reconcileUntilStable(t, r, "classed")
+	if pool := getDeployment(t, r, "classed").Spec.Template.Spec.NodeSelector["pool"]; pool != "a100" {
+		t.Fatalf("expected the edited class node selector, got %q", pool)
+	}
+}
+
+func TestModelServeClassPolicyEnforced(t *testing.T) {
+	class := &modelv1alpha1.ModelServeClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "small"},
+		Spec:       modelv1alpha1.ModelServeClassSpec{MaxMemoryLimit: 8192},
+	}
+	ms := newTestModelServe("oversized")
+	ms.Spec.ClassName = "small"
+	ms.Spec.MemoryLimit = 16384
+	r := newTestReconciler(t, class, ms)
+	reconcileUntilStable(t, r, "oversized")
+
+	got := getModelServe(t, r, "oversized")
+	if got.Status.Phase != "Failed" || got.Status.FailureReason != failureClassPolicy {
+		t.Fatalf("expected the model to fail its class policy, got %s (%s): %s", got.Status.Phase, got.Status.FailureReason, got.Status.Message)
+	}
+	if err := r.Get(context.Background(), types.NamespacedName{Name: "oversized", Namespace: "default"}, &appsv1.Deployment{}); !errors.IsNotFound(err) {
+		t.Fatalf("expected nothing deployed outside the class, got %v", err)
+	}
+
+	// Fitting the model into the class resumes it
+	got.Spec.MemoryLimit = 4096
+	if err := r.Update(context.Background(), got); err != nil {
+		t.Fatal(err)
+	}
+	reconcileUntilStable(t, r, "oversized")
+	if got := getModelServe(t, r, "oversized"); got.Status.FailureReason != "" {
+		t.Fatalf("expected the class failure to clear, got %s: %s", got.Status.FailureReason, got.Status.Message)
+	}
+	getDeployment(t, r, "oversized")
+}
+
+func TestMissingModelServeClassWaits(t *testing.T) {
+	ms := newTestModelServe("unclassed")
+	ms.Spec.ClassName = "missing"
+	r := newTestReconciler(t, ms)
+	reconcileUntilStable(t, r, "unclassed")
+
+	got := getModelServe(t, r, "unclassed")
+	if got.Status.Phase != "Pending" || got.Status.Message != "Waiting for ModelServeClass missing" {
+		t.Fatalf("expected the model to wait for its class, got %s: %s", got.Status.Phase, got.Status.Message)
+	}
+	if err := r.Get(context.Background(), types.NamespacedName{Name: "unclassed", Namespace: "default"}, &corev1.Service{}); !errors.IsNotFound(err) {
+		t.Fatalf("expected nothing deployed without the class, got %v", err)
+	}
+}
+
+func TestModelServeClassPolicyChecksDeployedValues(t *testing.T) {
+	tests := []struct {
+		name  string
+		class modelv1alpha1.ModelServeClassSpec
+		force string
+		fails bool
+	}{
+		{
+			name:  "unset image deploys the default image",
+			class: modelv1alpha1.ModelServeClassSpec{AllowedImages: []string{"ghcr.io/ggerganov/llama.cpp:*"}},
+		},
+		{
+			name:  "forced image",
+			class: modelv1alpha1.ModelServeClassSpec{AllowedImages: []string{"ghcr.io/ggerganov/llama.cpp:*"}},
+			force: "registry.example.com/llama:pinned",
+			fails: true,
+		},
+		{
+			name:  "unset memory limit deploys 4096MB",
+			class: modelv1alpha1.ModelServeClassSpec{MaxMemoryLimit: 2048},
+			fails: true,
+		},
+		{
+			name:  "unset cpu limit deploys 2000m",
+			class: modelv1alpha1.ModelServeClassSpec{MaxCPULimit: 1000},
+			fails: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("FORCE_IMAGE", tt.force)
+			class := &modelv1alpha1.ModelServeClass{ObjectMeta: metav1.ObjectMeta{Name: "limited"}, Spec: tt.class}
+			ms := newTestModelServe("defaulted")
+			ms.Spec.ClassName = "limited"
+			r := newTestReconciler(t, class, ms)
+			reconcileUntilStable(t, r, "defaulted")
+
+			got := getModelServe(t, r, "defaulted")
+			if failed := got.Status.FailureReason == failureClassPolicy; failed != tt.fails {
+				t.Fatalf("expected class failure %v, got %s (%s): %s", tt.fails, got.Status.Phase, got.Status.FailureReason, got.Status.Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	modelv1alpha1 "github.com/example/model-operator/api/v1alpha1"
+)
+
+// completionProbeScript posts the request body in $1 to the completion
+// endpoint and passes only when the server answers with generated content.
+// Both the curl timeout and the probe timeout come from the spec.
+const completionProbeScript = `out=$(curl -sf --max-time "$2" -H 'Content-Type: application/json' -d "$1" http://127.0.0.1:8080/completion) &&
+echo "$out" | grep -q '"content" *: *"[^"]'`
+
+// completionProbeForModelServe returns the readiness probe completing the
+// configured prompt. A few tokens are enough to tell a working model from one
+// that loads but cannot generate.
+func completionProbeForModelServe(m *modelv1alpha1.ModelServe) *corev1.Probe {
+	prompt := m.Spec.CompletionProbe.Prompt
+	if prompt == "" {
+		prompt = "Hello"
+	}
+	timeout := m.Spec.CompletionProbe.TimeoutSeconds
+	if timeout <= 0 {
+		timeout = 10
+	}
+
+	// Marshalling a map of plain values cannot fail
+	body, _ := json.Marshal(map[string]interface{}{"prompt": prompt, "n_predict": 4})
+
+	return &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			Exec: &corev1.ExecAction{
+				Command: []string{"/bin/sh", "-c", completionProbeScript, "completion-probe", string(body), fmt.Sprint(timeout)},
+			},
+		},
+		InitialDelaySeconds: 30,
+		PeriodSeconds:       30,
+		TimeoutSeconds:      timeout + 1,
+	}
+}
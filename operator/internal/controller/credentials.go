@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	modelv1alpha1 "github.com/example/model-operator/api/v1alpha1"
+)
+
+// minioCredentialsSecret holds the MinIO keys the downloads authenticate with.
+// It must exist in the namespace of every ModelServe.
+const minioCredentialsSecret = "inference-secrets"
+
+// failureCredentialsMissing marks a model whose download could not start for
+// want of the MinIO credentials
+const failureCredentialsMissing = "CredentialsMissing"
+
+// credentialsRecheckInterval is how often a model missing its credentials
+// looks for the Secret again; Secrets are not watched
+const credentialsRecheckInterval = 30 * time.Second
+
+// checkCredentials fails the model instead of creating pods that cannot start
+// while the credentials Secret or one of its keys is missing. A model that
+// failed this way resumes once the Secret is complete. It reports true while
+// the credentials are missing.
+func (r *ModelServeReconciler) checkCredentials(ctx context.Context, m *modelv1alpha1.ModelServe) (bool, ctrl.Result, error) {
+	problem := ""
+	secret := &corev1.Secret{}
+	err := r.apiReader().Get(ctx, types.NamespacedName{Name: minioCredentialsSecret, Namespace: m.Namespace}, secret)
+	switch {
+	case errors.IsNotFound(err):
+		problem = fmt.Sprintf("MinIO credentials Secret %s not found in namespace %s", minioCredentialsSecret, m.Namespace)
+	case err != nil:
+		return true, ctrl.Result{}, err
+	default:
+		for _, env := range minioCredentialsEnv() {
+			key := env.ValueFrom.SecretKeyRef.Key
+			if _, ok := secret.Data[key]; !ok {
+				problem = fmt.Sprintf("MinIO credentials Secret %s has no key %s", minioCredentialsSecret, key)
+				break
+			}
+		}
+	}
+
+	if problem == "" {
+		if m.Status.FailureReason != failureCredentialsMissing {
+			return false, ctrl.Result{}, nil
+		}
+		m.Status.Phase = "Pending"
+		m.Status.Message = "MinIO credentials found"
+		m.Status.FailureReason = ""
+		if err := r.updateStatus(ctx, m); err != nil {
+			return true, ctrl.Result{}, err
+		}
+		return false, ctrl.Result{}, nil
+	}
+
+	if m.Status.Phase != "Failed" || m.Status.Message != problem {
+		r.Recorder.Event(m, corev1.EventTypeWarning, failureCredentialsMissing, problem)
+		m.Status.Phase = "Failed"
+		m.Status.Message = problem
+		m.Status.FailureReason = failureCredentialsMissing
+		if err := r.updateStatus(ctx, m); err != nil {
+			return true, ctrl.Result{}, err
+		}
+	}
+	return true, ctrl.Result{RequeueAfter: credentialsRecheckInterval}, nil
+}
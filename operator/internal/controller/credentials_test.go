@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestMissingCredentialsFailInsteadOfDeploying(t *testing.T) {
+	ms := newTestModelServe("nocreds")
+	r := newTestReconciler(t, ms)
+	secret := &corev1.Secret{}
+	key := types.NamespacedName{Name: minioCredentialsSecret, Namespace: "default"}
+	if err := r.Get(context.Background(), key, secret); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Delete(context.Background(), secret); err != nil {
+		t.Fatal(err)
+	}
+	reconcileUntilStable(t, r, "nocreds")
+
+	status := getModelServe(t, r, "nocreds").Status
+	if status.Phase != "Failed" || status.FailureReason != failureCredentialsMissing {
+		t.Fatalf("expected Failed with %s, got %s/%s", failureCredentialsMissing, status.Phase, status.FailureReason)
+	}
+	if !strings.Contains(status.Message, minioCredentialsSecret) {
+		t.Fatalf("expected the Secret name in the message, got %q", status.Message)
+	}
+	deployment := types.NamespacedName{Name: "nocreds", Namespace: "default"}
+	if err := r.Get(context.Background(), deployment, &appsv1.Deployment{}); !errors.IsNotFound(err) {
+		t.Fatalf("expected no Deployment without credentials, got %v", err)
+	}
+
+	// Creating the Secret resumes the model
+	secret.ResourceVersion = ""
+	if err := r.Create(context.Background(), secret); err != nil {
+		t.Fatal(err)
+	}
+	reconcileUntilStable(t, r, "nocreds")
+
+	if reason := getModelServe(t, r, "nocreds").Status.FailureReason; reason != "" {
+		t.Fatalf("expected the failure to clear, got %q", reason)
+	}
+	if err := r.Get(context.Background(), deployment, &appsv1.Deployment{}); err != nil {
+		t.Fatalf("expected the Deployment once the credentials exist: %v", err)
+	}
+}
+
+func TestCredentialsReadThroughAPIReader(t *testing.T) {
+	ms := newTestModelServe("uncached")
+	r := newTestReconciler(t, ms)
+
+	// The Secret is only read from the API server, never from the cache
+	r.APIReader = fake.NewClientBuilder().WithScheme(r.Scheme).Build()
+	reconcileUntilStable(t, r, "uncached")
+
+	if reason := getModelServe(t, r, "uncached").Status.FailureReason; reason != failureCredentialsMissing {
+		t.Fatalf("expected the Secret to be looked up through the API reader, got %q", reason)
+	}
+}
@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	modelv1alpha1 "github.com/example/model-operator/api/v1alpha1"
+)
+
+// dcgmPort is the port the DCGM exporter sidecar serves its Prometheus metrics on
+const dcgmPort = 9400
+
+// dcgmContainerName is the name of the DCGM exporter sidecar
+const dcgmContainerName = "dcgm-exporter"
+
+// podResourcesDir is where the kubelet reports which pod holds which GPU
+const podResourcesDir = "/var/lib/kubelet/pod-resources"
+
+// dcgmExporter reports whether the model pods carry the DCGM exporter. It only
+// runs next to a server that requests GPUs.
+func dcgmExporter(m *modelv1alpha1.ModelServe) bool {
+	return m.Spec.Monitoring != nil && m.Spec.Monitoring.DCGM && m.GPUs() > 0
+}
+
+// dcgmExporterContainer returns the sidecar exporting the GPU metrics of the
+// pod. DCGM_EXPORTER_IMAGE overrides the image for air gapped registries. The
+// sidecar requests no GPU of its own, so it sees the GPUs of the node and maps
+// them to the server through the pod resources of the kubelet. DCGM needs
+// root and SYS_ADMIN for that, which exempts the sidecar from the restricted
+// profile and the pod from namespaces enforcing it.
+func dcgmExporterContainer() corev1.Container {
+	runAsNonRoot := false
+	root := int64(0)
+	return corev1.Container{
+		Name:  dcgmContainerName,
+		Image: getEnvOrDefault("DCGM_EXPORTER_IMAGE", "nvcr.io/nvidia/k8s/dcgm-exporter:3.3.5-3.4.0-ubuntu22.04"),
+		Ports: []corev1.ContainerPort{{
+			ContainerPort: dcgmPort,
+			Name:          "dcgm",
+		}},
+		Env: []corev1.EnvVar{
+			{Name: "DCGM_EXPORTER_LISTEN", Value: fmt.Sprintf(":%d", dcgmPort)},
+			{Name: "DCGM_EXPORTER_KUBERNETES", Value: "true"},
+			{Name: "NVIDIA_VISIBLE_DEVICES", Value: "all"},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "pod-resources", MountPath: podResourcesDir, ReadOnly: true},
+		},
+		SecurityContext: &corev1.SecurityContext{
+			RunAsNonRoot: &runAsNonRoot,
+			RunAsUser:    &root,
+			Capabilities: &corev1.Capabilities{Add: []corev1.Capability{"SYS_ADMIN"}},
+		},
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceMemory: resource.MustParse("128Mi"),
+				corev1.ResourceCPU:    resource.MustParse("50m"),
+			},
+			Limits: corev1.ResourceList{
+				corev1.ResourceMemory: resource.MustParse("256Mi"),
+				corev1.ResourceCPU:    resource.MustParse("200m"),
+			},
+		},
+	}
+}
+
+// podResourcesVolume mounts the pod resources of the kubelet for the sidecar
+func podResourcesVolume() corev1.Volume {
+	return corev1.Volume{
+		Name: "pod-resources",
+		VolumeSource: corev1.VolumeSource{
+			HostPath: &corev1.HostPathVolumeSource{Path: podResourcesDir},
+		},
+	}
+}
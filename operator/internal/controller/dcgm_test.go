@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	modelv1alpha1 "github.com/example/model-operator/api/v1alpha1"
+)
+
+func TestDCGMExporterOnlyForGPUModels(t *testing.T) {
+	gpu := newTestModelServe("gpu-model")
+	gpuCount := int32(1)
+	gpu.Spec.GPUCount = &gpuCount
+	gpu.Spec.Monitoring = &modelv1alpha1.MonitoringSpec{DCGM: true}
+	cpu := newTestModelServe("cpu-model")
+	cpu.Spec.Monitoring = &modelv1alpha1.MonitoringSpec{DCGM: true}
+	r := newTestReconciler(t, gpu, cpu)
+	reconcileUntilStable(t, r, "gpu-model")
+	reconcileUntilStable(t, r, "cpu-model")
+
+	for _, tc := range []struct {
+		name string
+		want bool
+	}{
+		{name: "gpu-model", want: true},
+		{name: "cpu-model", want: false},
+	} {
+		var sidecar *corev1.Container
+		containers := getDeployment(t, r, tc.name).Spec.Template.Spec.Containers
+		for i := range containers {
+			if containers[i].Name == dcgmContainerName {
+				sidecar = &containers[i]
+			}
+		}
+		if (sidecar != nil) != tc.want {
+			t.Fatalf("%s: expected DCGM sidecar %v, got %v", tc.name, tc.want, sidecar != nil)
+		}
+		if sidecar != nil && (len(sidecar.Ports) != 1 || sidecar.Ports[0].ContainerPort != dcgmPort) {
+			t.Fatalf("%s: expected the sidecar to listen on %d, got %v", tc.name, dcgmPort, sidecar.Ports)
+		}
+		if sidecar != nil {
+			// DCGM keeps the privileges it needs under the restricted profile
+			sc := sidecar.SecurityContext
+			if sc == nil || sc.Capabilities == nil || len(sc.Capabilities.Add) != 1 || sc.Capabilities.Add[0] != "SYS_ADMIN" {
+				t.Fatalf("%s: expected the sidecar to keep SYS_ADMIN, got %+v", tc.name, sc)
+			}
+			if len(sidecar.VolumeMounts) != 1 || sidecar.VolumeMounts[0].MountPath != podResourcesDir {
+				t.Fatalf("%s: expected the kubelet pod resources mounted, got %+v", tc.name, sidecar.VolumeMounts)
+			}
+			mounted := false
+			for _, v := range getDeployment(t, r, tc.name).Spec.Template.Spec.Volumes {
+				if v.HostPath != nil && v.HostPath.Path == podResourcesDir {
+					mounted = true
+				}
+			}
+			if !mounted {
+				t.Fatalf("%s: expected a pod resources host path volume", tc.name)
+			}
+		}
+
+		svc := &corev1.Service{}
+		if err := r.Get(context.Background(), types.NamespacedName{Name: tc.name, Namespace: "default"}, svc); err != nil {
+			t.Fatal(err)
+		}
+		exposed := false
+		for _, p := range svc.Spec.Ports {
+			if p.Name == "dcgm" && p.Port == dcgmPort {
+				exposed = true
+			}
+		}
+		if exposed != tc.want {
+			t.Fatalf("%s: expected DCGM Service port %v, got %v", tc.name, tc.want, svc.Spec.Ports)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	modelv1alpha1 "github.com/example/model-operator/api/v1alpha1"
+)
+
+// terminationTaints are taints cloud providers and node termination handlers
+// put on a node shortly before it goes away
+var terminationTaints = map[string]bool{
+	"aws-node-termination-handler/spot-itn":                 true,
+	"aws-node-termination-handler/rebalance-recommendation": true,
+	"cloud.google.com/impending-node-termination":           true,
+	"ToBeDeletedByClusterAutoscaler":                        true,
+}
+
+// nodeTerminating reports whether the node is about to evict its pods
+func nodeTerminating(node *corev1.Node) bool {
+	for _, taint := range node.Spec.Taints {
+		if taint.Effect == corev1.TaintEffectNoExecute || terminationTaints[taint.Key] {
+			return true
+		}
+	}
+	return false
+}
+
+// evictionSurge returns the number of extra replicas to run while model pods
+// sit on terminating nodes, so replacements start before the eviction completes
+func (r *ModelServeReconciler) evictionSurge(ctx context.Context, m *modelv1alpha1.ModelServe) (int32, error) {
+	if m.Spec.EvictionPolicy != modelv1alpha1.EvictionPolicyPreScale {
+		return 0, nil
+	}
+
+	podList := &corev1.PodList{}
+	if err := r.List(ctx, podList, client.InNamespace(m.Namespace), client.MatchingLabels(labelsForModelServe(m.Name))); err != nil {
+		return 0, err
+	}
+
+	var surge int32
+	for _, pod := range podList.Items {
+		if pod.Spec.NodeName == "" || pod.DeletionTimestamp != nil {
+			continue
+		}
+		node := &corev1.Node{}
+		if err := r.Get(ctx, client.ObjectKey{Name: pod.Spec.NodeName}, node); err != nil {
+			if client.IgnoreNotFound(err) != nil {
+				return 0, err
+			}
+			continue
+		}
+		if nodeTerminating(node) {
+			surge++
+		}
+	}
+	return surge, nil
+}
+
+// modelServesForNode maps a node event to the ModelServes with pods on a terminating node
+func (r *ModelServeReconciler) modelServesForNode(ctx context.Context, obj client.Object) []reconcile.Request {
+	node, ok := obj.(*corev1.Node)
+	if !ok || !nodeTerminating(node) {
+		return nil
+	}
+
+	podList := &corev1.PodList{}
+	if err := r.List(ctx, podList, client.MatchingLabels{"app": "model-serve"}); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list model pods for node", "Node", node.Name)
+		return nil
+	}
+
+	seen := map[client.ObjectKey]bool{}
+	var requests []reconcile.Request
+	for _, pod := range podList.Items {
+		name := pod.Labels["model_serve_cr"]
+		key := client.ObjectKey{Name: name, Namespace: pod.Namespace}
+		if pod.Spec.NodeName != node.Name || name == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		requests = append(requests, reconcile.Request{NamespacedName: key})
+	}
+	return requests
+}
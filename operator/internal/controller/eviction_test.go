@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	modelv1alpha1 "github.com/example/model-operator/api/v1alpha1"
+)
+
+func TestPreScaleOnNodeTermination(t *testing.T) {
+	ms := newTestModelServe("spot")
+	ms.Spec.EvictionPolicy = modelv1alpha1.EvictionPolicyPreScale
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "spot-1"}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "spot-abc", Namespace: "default", Labels: labelsForModelServe("spot")},
+		Spec:       corev1.PodSpec{NodeName: "spot-1"},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	r := newTestReconciler(t, ms, node, pod)
+	reconcileUntilStable(t, r, "spot")
+
+	if replicas := *getDeployment(t, r, "spot").Spec.Replicas; replicas != 1 {
+		t.Fatalf("expected 1 replica on a healthy node, got %d", replicas)
+	}
+
+	// The spot instance receives its termination notice
+	node.Spec.Taints = []corev1.Taint{{Key: "aws-node-termination-handler/spot-itn", Effect: corev1.TaintEffectNoSchedule}}
+	if err := r.Update(context.Background(), node); err != nil {
+		t.Fatal(err)
+	}
+	reqs := r.modelServesForNode(context.Background(), node)
+	if len(reqs) != 1 || reqs[0].Name != "spot" {
+		t.Fatalf("expected the node taint to enqueue spot, got %v", reqs)
+	}
+
+	reconcileUntilStable(t, r, "spot")
+	if replicas := *getDeployment(t, r, "spot").Spec.Replicas; replicas != 2 {
+		t.Fatalf("expected a replacement replica to be scheduled, got %d replicas", replicas)
+	}
+
+	// Once the pod is evicted the surge is removed again
+	if err := r.Delete(context.Background(), pod); err != nil {
+		t.Fatal(err)
+	}
+	reconcileUntilStable(t, r, "spot")
+	if replicas := *getDeployment(t, r, "spot").Spec.Replicas; replicas != 1 {
+		t.Fatalf("expected the surge to end after eviction, got %d replicas", replicas)
+	}
+}
@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	modelv1alpha1 "github.com/example/model-operator/api/v1alpha1"
+)
+
+// gatewayURL returns the external URL of the model. The host comes from
+// GATEWAY_HOST and the port from GATEWAY_PORT or, when unset, from the
+// gateway Service: its node port for NodePort Services, otherwise its web port.
+func (r *ModelServeReconciler) gatewayURL(ctx context.Context, m *modelv1alpha1.ModelServe) string {
+	host := getEnvOrDefault("GATEWAY_HOST", "localhost")
+
+	port := os.Getenv("GATEWAY_PORT")
+	if port == "" {
+		port = r.gatewayServicePort(ctx)
+	}
+	if port != "" && port != "80" {
+		host += ":" + port
+	}
+	return "http://" + host + m.RoutePath()
+}
+
+// gatewayServicePort returns the port clients reach the gateway Service on,
+// or an empty string when the Service cannot be read
+func (r *ModelServeReconciler) gatewayServicePort(ctx context.Context) string {
+	svc := &corev1.Service{}
+	key := types.NamespacedName{
+		Name:      getEnvOrDefault("TRAEFIK_SERVICE", "traefik"),
+		Namespace: getEnvOrDefault("TRAEFIK_NAMESPACE", "kube-system"),
+	}
+	if err := r.Get(ctx, key, svc); err != nil {
+		log.FromContext(ctx).V(1).Info("Gateway Service not available", "Service", key.String(), "error", err.Error())
+		return ""
+	}
+	if len(svc.Spec.Ports) == 0 {
+		return ""
+	}
+
+	// The plain HTTP entrypoint, by name or by port
+	web := svc.Spec.Ports[0]
+	for _, p := range svc.Spec.Ports {
+		if p.Name == "web" || p.Port == 80 {
+			web = p
+			break
+		}
+	}
+
+	if svc.Spec.Type == corev1.ServiceTypeNodePort && web.NodePort != 0 {
+		return fmt.Sprint(web.NodePort)
+	}
+	return fmt.Sprint(web.Port)
+}
@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestGatewayURLIncludesExternalPort(t *testing.T) {
+	gateway := func(serviceType corev1.ServiceType) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "traefik", Namespace: "kube-system"},
+			Spec: corev1.ServiceSpec{
+				Type: serviceType,
+				Ports: []corev1.ServicePort{
+					{Name: "websecure", Port: 443, NodePort: 30443},
+					{Name: "web", Port: 80, NodePort: 30080},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		gateway *corev1.Service
+		port    string
+		want    string
+	}{
+		{name: "node port", gateway: gateway(corev1.ServiceTypeNodePort), want: "http://localhost:30080/url"},
+		{name: "load balancer on port 80", gateway: gateway(corev1.ServiceTypeLoadBalancer), want: "http://localhost/url"},
+		{name: "configured port wins", gateway: gateway(corev1.ServiceTypeNodePort), port: "8080", want: "http://localhost:8080/url"},
+		{name: "no gateway Service", want: "http://localhost/url"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GATEWAY_PORT", tt.port)
+			objs := []client.Object{newTestModelServe("url")}
+			if tt.gateway != nil {
+				objs = append(objs, tt.gateway)
+			}
+			r := newTestReconciler(t, objs...)
+			reconcileUntilStable(t, r, "url")
+
+			if got := getModelServe(t, r, "url").Status.GatewayURL; got != tt.want {
+				t.Fatalf("expected gateway URL %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
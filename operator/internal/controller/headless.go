@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	modelv1alpha1 "github.com/example/model-operator/api/v1alpha1"
+)
+
+// headlessServiceName is the name of the Service publishing per pod DNS
+func headlessServiceName(m *modelv1alpha1.ModelServe) string {
+	return m.Name + "-headless"
+}
+
+// reconcileHeadlessService keeps a headless Service next to the main one while
+// spec.headlessService is set. The pods use it as their subdomain, so every
+// replica resolves as <pod>.<name>-headless.<namespace>.svc.
+func (r *ModelServeReconciler) reconcileHeadlessService(ctx context.Context, m *modelv1alpha1.ModelServe) error {
+	found := &corev1.Service{}
+	err := r.Get(ctx, types.NamespacedName{Name: headlessServiceName(m), Namespace: m.Namespace}, found)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	exists := err == nil
+
+	if !m.Spec.HeadlessService {
+		if exists && metav1.IsControlledBy(found, m) {
+			return r.Delete(ctx, found)
+		}
+		return nil
+	}
+
+	svc := r.headlessServiceForModelServe(m)
+	if err := ctrl.SetControllerReference(m, svc, r.Scheme); err != nil {
+		return err
+	}
+
+	if !exists {
+		return r.Create(ctx, svc)
+	}
+	if !servicePortsMatch(found.Spec.Ports, svc.Spec.Ports) || !equality.Semantic.DeepEqual(found.Spec.Selector, svc.Spec.Selector) {
+		found.Spec.Ports = svc.Spec.Ports
+		found.Spec.Selector = svc.Spec.Selector
+		return r.Update(ctx, found)
+	}
+	return nil
+}
+
+// headlessServiceForModelServe returns a Service without a cluster IP over the
+// model pods and the ports of the main Service
+func (r *ModelServeReconciler) headlessServiceForModelServe(m *modelv1alpha1.ModelServe) *corev1.Service {
+	svc := r.serviceForModelServe(m)
+	svc.Name = headlessServiceName(m)
+	svc.Spec.ClusterIP = corev1.ClusterIPNone
+	return svc
+}
@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestHeadlessServiceForPodDNS(t *testing.T) {
+	ms := newTestModelServe("sticky")
+	ms.Spec.HeadlessService = true
+	r := newTestReconciler(t, ms)
+	reconcileUntilStable(t, r, "sticky")
+
+	headless := &corev1.Service{}
+	key := types.NamespacedName{Name: "sticky-headless", Namespace: "default"}
+	if err := r.Get(context.Background(), key, headless); err != nil {
+		t.Fatalf("expected the headless Service: %v", err)
+	}
+	if headless.Spec.ClusterIP != corev1.ClusterIPNone {
+		t.Fatalf("expected ClusterIP None, got %q", headless.Spec.ClusterIP)
+	}
+	if headless.Spec.Selector["model_serve_cr"] != "sticky" {
+		t.Fatalf("expected the headless Service to select the model pods, got %v", headless.Spec.Selector)
+	}
+
+	// The main Service keeps its cluster IP
+	main := &corev1.Service{}
+	if err := r.Get(context.Background(), types.NamespacedName{Name: "sticky", Namespace: "default"}, main); err != nil {
+		t.Fatal(err)
+	}
+	if main.Spec.ClusterIP == corev1.ClusterIPNone {
+		t.Fatal("expected the main Service not to be headless")
+	}
+	if subdomain := getDeployment(t, r, "sticky").Spec.Template.Spec.Subdomain; subdomain != "sticky-headless" {
+		t.Fatalf("expected the pods to use the headless Service as subdomain, got %q", subdomain)
+	}
+
+	// Turning the option off removes the Service again
+	ms = getModelServe(t, r, "sticky")
+	ms.Spec.HeadlessService = false
+	if err := r.Update(context.Background(), ms); err != nil {
+		t.Fatal(err)
+	}
+	reconcileUntilStable(t, r, "sticky")
+	if err := r.Get(context.Background(), key, &corev1.Service{}); !errors.IsNotFound(err) {
+		t.Fatalf("expected the headless Service to be deleted, got %v", err)
+	}
+}
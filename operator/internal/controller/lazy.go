@@ -0,0 +1,318 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	modelv1alpha1 "github.com/example/model-operator/api/v1alpha1"
+)
+
+const (
+	// activationRequestedAnnotation is set on the ModelServe by the activator
+	// when the first request for a lazily downloaded model arrives
+	activationRequestedAnnotation = modelv1alpha1.ActivationRequestedAnnotation
+
+	// activatorAnnotation marks a pod template running the activator, so the
+	// switch to the real server rolls the Deployment
+	activatorAnnotation = "model.example.com/activator"
+
+	// activatorApp labels the activator objects
+	activatorApp = "modelserve-activator"
+
+	// activatorScriptConfigMap holds the activator of a namespace
+	activatorScriptConfigMap = "activator-script"
+)
+
+// lazyDownload reports whether the model is downloaded on the first request
+func lazyDownload(m *modelv1alpha1.ModelServe) bool {
+	return m.Spec.ModelDownloadMode == modelv1alpha1.ModelDownloadModeLazy
+}
+
+// awaitingActivation reports whether the pods still run the activator
+func awaitingActivation(m *modelv1alpha1.ModelServe) bool {
+	return lazyDownload(m) && m.Status.ActivatedAt == nil
+}
+
+// lazyDownloadJobName is the name of the Job downloading a lazy model
+func lazyDownloadJobName(m *modelv1alpha1.ModelServe) string {
+	return m.Name + "-download"
+}
+
+// reconcileActivation starts the download Job once the activator reported the
+// first request and records the activation when the Job completes. The model
+// lands on the persistent model volume, so the server pods find it in place.
+func (r *ModelServeReconciler) reconcileActivation(ctx context.Context, m *modelv1alpha1.ModelServe) error {
+	if !awaitingActivation(m) || m.Annotations[activationRequestedAnnotation] == "" {
+		return nil
+	}
+
+	job := &batchv1.Job{}
+	err := r.Get(ctx, types.NamespacedName{Name: lazyDownloadJobName(m), Namespace: m.Namespace}, job)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	if errors.IsNotFound(err) {
+		job = downloadJob(m, lazyDownloadJobName(m),
+			map[string]string{"app": "model-download", "model_serve_cr": m.Name},
+			modelClaimName(m), modelFileName(m))
+		if err := ctrl.SetControllerReference(m, job, r.Scheme); err != nil {
+			return err
+		}
+		if err := r.Create(ctx, job); err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+		r.Recorder.Event(m, corev1.EventTypeNormal, "ActivationRequested",
+			"First request received, downloading the model")
+
+		m.Status.Phase = "Downloading"
+		m.Status.Message = "Downloading model for the first request"
+		return r.updateStatus(ctx, m)
+	}
+
+	for _, cond := range job.Status.Conditions {
+		if cond.Type == batchv1.JobFailed && cond.Status == corev1.ConditionTrue {
+			return fmt.Errorf("model download job %s failed: %s", job.Name, cond.Message)
+		}
+	}
+	if job.Status.Succeeded == 0 {
+		return nil
+	}
+
+	now := metav1.NewTime(time.Now())
+	m.Status.ActivatedAt = &now
+	m.Status.Message = "Model downloaded, starting model server"
+	return r.updateStatus(ctx, m)
+}
+
+// useActivator replaces the model server pod with the activator. The activator
+// answers every request with 503 and Retry-After and asks the operator for the
+// model by annotating the ModelServe on the first one.
+func useActivator(dep *appsv1.Deployment, m *modelv1alpha1.ModelServe) {
+	template := &dep.Spec.Template
+	template.Annotations[activatorAnnotation] = "true"
+
+	automountToken := true
+	template.Spec = corev1.PodSpec{
+		ServiceAccountName:           modelv1alpha1.ActivatorServiceAccountName(m.Name),
+		AutomountServiceAccountToken: &automountToken,
+		Containers: []corev1.Container{{
+			Name:    "activator",
+			Image:   "python:3.9-slim",
+			Command: []string{"python", "/scripts/activator.py"},
+			Ports: []corev1.ContainerPort{{
+				ContainerPort: 8080,
+				Name:          "http",
+			}},
+			Env: []corev1.EnvVar{
+				{Name: "MODELSERVE_NAME", Value: m.Name},
+				{Name: "MODELSERVE_NAMESPACE", Value: m.Namespace},
+			},
+			VolumeMounts: []corev1.VolumeMount{
+				{Name: "activator-script", MountPath: "/scripts"},
+			},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceMemory: resource.MustParse("32Mi"),
+					corev1.ResourceCPU:    resource.MustParse("10m"),
+				},
+				Limits: corev1.ResourceList{
+					corev1.ResourceMemory: resource.MustParse("64Mi"),
+					corev1.ResourceCPU:    resource.MustParse("100m"),
+				},
+			},
+			ReadinessProbe: &corev1.Probe{
+				ProbeHandler: corev1.ProbeHandler{
+					HTTPGet: &corev1.HTTPGetAction{
+						Path: "/health",
+						Port: intstr.FromInt(8080),
+					},
+				},
+				PeriodSeconds: 10,
+			},
+		}},
+		Volumes: []corev1.Volume{{
+			Name: "activator-script",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: activatorScriptConfigMap},
+				},
+			},
+		}},
+	}
+	applySecurityProfile(&template.Spec, m)
+}
+
+// ensureActivator creates the activator script and the service account of the
+// activator of m. The script is shared by the lazy models of the namespace and
+// owned by none, like the warmup backend. The service account is owned by the
+// model and may only get and patch that ModelServe, as the activator answers
+// requests from outside the cluster.
+func (r *ModelServeReconciler) ensureActivator(ctx context.Context, m *modelv1alpha1.ModelServe) error {
+	namespace := m.Namespace
+	name := modelv1alpha1.ActivatorServiceAccountName(m.Name)
+	ls := map[string]string{"app": activatorApp, "model_serve_cr": m.Name}
+
+	owned := []client.Object{
+		&corev1.ServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: ls},
+		},
+		&rbacv1.Role{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: ls},
+			Rules: []rbacv1.PolicyRule{{
+				APIGroups:     []string{modelv1alpha1.GroupVersion.Group},
+				Resources:     []string{"modelserves"},
+				ResourceNames: []string{m.Name},
+				Verbs:         []string{"get", "patch"},
+			}},
+		},
+		&rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: ls},
+			Subjects: []rbacv1.Subject{{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      name,
+				Namespace: namespace,
+			}},
+			RoleRef: rbacv1.RoleRef{
+				APIGroup: rbacv1.GroupName,
+				Kind:     "Role",
+				Name:     name,
+			},
+		},
+	}
+	for _, obj := range owned {
+		if err := ctrl.SetControllerReference(m, obj, r.Scheme); err != nil {
+			return err
+		}
+	}
+
+	objs := append([]client.Object{
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: activatorScriptConfigMap, Namespace: namespace, Labels: map[string]string{"app": activatorApp}},
+			Data:       map[string]string{"activator.py": activatorScript},
+		},
+	}, owned...)
+
+	for _, obj := range objs {
+		existing := obj.DeepCopyObject().(client.Object)
+		err := r.Get(ctx, types.NamespacedName{Name: obj.GetName(), Namespace: namespace}, existing)
+		if errors.IsNotFound(err) {
+			err = r.Create(ctx, obj)
+		}
+		if err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// activatorScript stands in for the model server until the first request
+const activatorScript = `#!/usr/bin/env python3
+"""
+Activator for lazily downloaded models.
+Stands in for llama.cpp server until the model is first requested. The first
+request annotates the ModelServe so the operator downloads the model and
+starts the real server; every request is answered with 503 and Retry-After.
+"""
+import json
+import os
+import ssl
+import threading
+import urllib.request
+from datetime import datetime, timezone
+from http.server import BaseHTTPRequestHandler, ThreadingHTTPServer
+
+NAME = os.environ["MODELSERVE_NAME"]
+NAMESPACE = os.environ["MODELSERVE_NAMESPACE"]
+PORT = int(os.environ.get("PORT", "8080"))
+RETRY_AFTER = os.environ.get("RETRY_AFTER", "30")
+
+SA_DIR = "/var/run/secrets/kubernetes.io/serviceaccount"
+API_URL = (
+    "https://kubernetes.default.svc/apis/model.example.com/v1alpha1"
+    f"/namespaces/{NAMESPACE}/modelserves/{NAME}"
+)
+
+activated = threading.Event()
+lock = threading.Lock()
+
+
+def request_activation():
+    """Annotate the ModelServe so the operator downloads the model."""
+    with open(f"{SA_DIR}/token") as f:
+        token = f.read().strip()
+
+    patch = {
+        "metadata": {
+            "annotations": {
+                "model.example.com/activation-requested": datetime.now(timezone.utc).isoformat()
+            }
+        }
+    }
+    req = urllib.request.Request(
+        API_URL,
+        data=json.dumps(patch).encode(),
+        method="PATCH",
+        headers={
+            "Authorization": f"Bearer {token}",
+            "Content-Type": "application/merge-patch+json",
+        },
+    )
+    context = ssl.create_default_context(cafile=f"{SA_DIR}/ca.crt")
+    urllib.request.urlopen(req, context=context, timeout=10).close()
+
+
+class Handler(BaseHTTPRequestHandler):
+    def do_GET(self):
+        self.handle_request()
+
+    def do_POST(self):
+        self.handle_request()
+
+    def handle_request(self):
+        if self.path == "/health":
+            self.respond(200, {"status": "ok"})
+            return
+
+        with lock:
+            if not activated.is_set():
+                try:
+                    request_activation()
+                    activated.set()
+                    print(f"Requested activation of {NAMESPACE}/{NAME}")
+                except Exception as e:
+                    print(f"Failed to request activation: {e}")
+
+        self.send_response(503)
+        self.send_header("Retry-After", RETRY_AFTER)
+        self.send_header("Content-Type", "application/json")
+        self.end_headers()
+        self.wfile.write(json.dumps({"error": "model is loading, retry later"}).encode())
+
+    def respond(self, code, body):
+        self.send_response(code)
+        self.send_header("Content-Type", "application/json")
+        self.end_headers()
+        self.wfile.write(json.dumps(body).encode())
+
+    def log_message(self, format, *args):
+        pass
+
+
+if __name__ == "__main__":
+    print(f"Activator for {NAMESPACE}/{NAME} listening on port {PORT}")
+    ThreadingHTTPServer(("0.0.0.0", PORT), Handler).serve_forever()
+`
@@ -0,0 +1,153 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	modelv1alpha1 "github.com/example/model-operator/api/v1alpha1"
+)
+
+func TestLazyDownloadOnFirstRequest(t *testing.T) {
+	ms := newTestModelServe("lazy")
+	ms.Spec.ModelDownloadMode = modelv1alpha1.ModelDownloadModeLazy
+	ms.Spec.Storage = &modelv1alpha1.StorageSpec{Size: "20Gi"}
+	r := newTestReconciler(t, ms)
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "lazy", Namespace: "default"}
+
+	reconcileUntilStable(t, r, "lazy")
+
+	// Until the first request only the activator runs, without downloading
+	podSpec := getDeployment(t, r, "lazy").Spec.Template.Spec
+	if len(podSpec.InitContainers) != 0 {
+		t.Fatalf("expected no init containers in lazy mode, got %+v", podSpec.InitContainers)
+	}
+	if len(podSpec.Containers) != 1 || podSpec.Containers[0].Name != "activator" {
+		t.Fatalf("expected a single activator container, got %+v", podSpec.Containers)
+	}
+	if podSpec.ServiceAccountName != modelv1alpha1.ActivatorServiceAccountName("lazy") {
+		t.Fatalf("expected the activator service account, got %q", podSpec.ServiceAccountName)
+	}
+	job := &batchv1.Job{}
+	if err := r.Get(ctx, types.NamespacedName{Name: "lazy-download", Namespace: "default"}, job); err == nil {
+		t.Fatal("expected no download Job before the first request")
+	}
+
+	// The activator annotates the ModelServe on the first request
+	if err := r.Get(ctx, key, ms); err != nil {
+		t.Fatal(err)
+	}
+	if ms.Status.Phase != "Standby" {
+		t.Fatalf("expected phase Standby before the first request, got %q", ms.Status.Phase)
+	}
+	ms.Annotations = map[string]string{activationRequestedAnnotation: "2024-01-01T00:00:00Z"}
+	if err := r.Update(ctx, ms); err != nil {
+		t.Fatal(err)
+	}
+	reconcileUntilStable(t, r, "lazy")
+
+	if err := r.Get(ctx, types.NamespacedName{Name: "lazy-download", Namespace: "default"}, job); err != nil {
+		t.Fatalf("expected a download Job after the first request: %v", err)
+	}
+	claim := job.Spec.Template.Spec.Volumes[0].PersistentVolumeClaim
+	if claim == nil || claim.ClaimName != "lazy-model" {
+		t.Fatalf("expected the Job to download into the model claim, got %+v", job.Spec.Template.Spec.Volumes)
+	}
+	if err := r.Get(ctx, key, ms); err != nil {
+		t.Fatal(err)
+	}
+	if ms.Status.Phase != "Downloading" {
+		t.Fatalf("expected phase Downloading while the Job runs, got %q", ms.Status.Phase)
+	}
+
+	// Once the model is downloaded the real server replaces the activator
+	job.Status.Succeeded = 1
+	if err := r.Update(ctx, job); err != nil {
+		t.Fatal(err)
+	}
+	reconcileUntilStable(t, r, "lazy")
+
+	template := getDeployment(t, r, "lazy").Spec.Template
+	if _, ok := template.Annotations[activatorAnnotation]; ok {
+		t.Fatal("expected the activator annotation to be removed after activation")
+	}
+	if len(template.Spec.Containers) == 0 || template.Spec.Containers[0].Name != "llama-server" {
+		t.Fatalf("expected the model server after activation, got %+v", template.Spec.Containers)
+	}
+	if err := r.Get(ctx, key, ms); err != nil {
+		t.Fatal(err)
+	}
+	if ms.Status.ActivatedAt == nil {
+		t.Fatal("expected status.activatedAt to be recorded")
+	}
+}
+
+func TestActivatorCreatedInModelNamespace(t *testing.T) {
+	ms := newTestModelServe("lazy")
+	ms.Namespace = "team-a"
+	ms.Spec.ModelDownloadMode = modelv1alpha1.ModelDownloadModeLazy
+	ms.Spec.Storage = &modelv1alpha1.StorageSpec{Size: "20Gi"}
+	ms.Spec.NetworkPolicy = &modelv1alpha1.NetworkPolicySpec{Enabled: true}
+	credentials := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: minioCredentialsSecret, Namespace: "team-a"},
+		Data:       map[string][]byte{"MINIO_ACCESS_KEY": []byte("a"), "MINIO_SECRET_KEY": []byte("b")},
+	}
+	r := newTestReconciler(t, ms, credentials)
+	ctx := context.Background()
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "lazy", Namespace: "team-a"}}
+	for i := 0; i < 3; i++ {
+		if _, err := r.Reconcile(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The activator pods find their script and permissions next to them
+	for _, obj := range []client.Object{&corev1.ConfigMap{}, &corev1.ServiceAccount{}, &rbacv1.Role{}, &rbacv1.RoleBinding{}} {
+		name := modelv1alpha1.ActivatorServiceAccountName("lazy")
+		if _, ok := obj.(*corev1.ConfigMap); ok {
+			name = activatorScriptConfigMap
+		}
+		if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: "team-a"}, obj); err != nil {
+			t.Fatalf("expected the activator %T in the model namespace: %v", obj, err)
+		}
+	}
+
+	// limited to the ModelServe they stand in for
+	role := &rbacv1.Role{}
+	if err := r.Get(ctx, types.NamespacedName{Name: modelv1alpha1.ActivatorServiceAccountName("lazy"), Namespace: "team-a"}, role); err != nil {
+		t.Fatal(err)
+	}
+	if len(role.Rules) != 1 || len(role.Rules[0].ResourceNames) != 1 || role.Rules[0].ResourceNames[0] != "lazy" {
+		t.Fatalf("expected the activator Role scoped to its ModelServe, got %+v", role.Rules)
+	}
+	if len(role.OwnerReferences) != 1 || role.OwnerReferences[0].Name != "lazy" {
+		t.Fatalf("expected the activator Role owned by its ModelServe, got %+v", role.OwnerReferences)
+	}
+
+	// and may reach the API server through the NetworkPolicy
+	np := &networkingv1.NetworkPolicy{}
+	if err := r.Get(ctx, req.NamespacedName, np); err != nil {
+		t.Fatal(err)
+	}
+	apiServer := false
+	for _, rule := range np.Spec.Egress {
+		for _, p := range rule.Ports {
+			if len(rule.To) == 0 && p.Port != nil && p.Port.IntValue() == 443 {
+				apiServer = true
+			}
+		}
+	}
+	if !apiServer {
+		t.Fatalf("expected egress to the API server for the activator, got %+v", np.Spec.Egress)
+	}
+}
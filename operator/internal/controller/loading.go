@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	modelv1alpha1 "github.com/example/model-operator/api/v1alpha1"
+)
+
+// loadingPollInterval is how often load progress is polled while the server starts
+const loadingPollInterval = 5 * time.Second
+
+// ProgressFetcher returns the load progress in percent reported by a model pod
+type ProgressFetcher func(ctx context.Context, pod *corev1.Pod, path string) (int32, error)
+
+// loadingEndpoint returns the configured load progress endpoint, if any
+func loadingEndpoint(m *modelv1alpha1.ModelServe) string {
+	if m.Spec.HealthCheck == nil {
+		return ""
+	}
+	return m.Spec.HealthCheck.LoadingEndpoint
+}
+
+// startupProbeForModelServe returns a startup probe on the loading endpoint.
+// It allows large models up to 30 minutes to load before liveness kicks in.
+func startupProbeForModelServe(m *modelv1alpha1.ModelServe) *corev1.Probe {
+	return &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			HTTPGet: &corev1.HTTPGetAction{
+				Path: loadingEndpoint(m),
+				Port: intstr.FromInt(8080),
+			},
+		},
+		PeriodSeconds:    5,
+		FailureThreshold: 360,
+	}
+}
+
+// loadingProgress returns the highest load progress reported by the running
+// but not yet ready model pods. It reports false when no pod answered.
+func (r *ModelServeReconciler) loadingProgress(ctx context.Context, m *modelv1alpha1.ModelServe) (int32, bool) {
+	fetch := r.ProgressFetcher
+	if fetch == nil {
+		fetch = fetchLoadingProgress
+	}
+
+	podList := &corev1.PodList{}
+	if err := r.List(ctx, podList, client.InNamespace(m.Namespace), client.MatchingLabels(labelsForModelServe(m.Name))); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list pods for load progress")
+		return 0, false
+	}
+
+	var progress int32
+	found := false
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" || podReady(pod) {
+			continue
+		}
+		p, err := fetch(ctx, pod, loadingEndpoint(m))
+		if err != nil {
+			log.FromContext(ctx).V(1).Info("Load progress not available", "Pod", pod.Name, "error", err.Error())
+			continue
+		}
+		if !found || p > progress {
+			progress = p
+			found = true
+		}
+	}
+	return progress, found
+}
+
+// podReady reports whether the pod has passed its readiness probe
+func podReady(pod *corev1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// fetchLoadingProgress queries the loading endpoint of the pod directly
+func fetchLoadingProgress(ctx context.Context, pod *corev1.Pod, path string) (int32, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://%s:8080%s", pod.Status.PodIP, path), nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		return 0, err
+	}
+	return parseLoadingProgress(body)
+}
+
+// parseLoadingProgress accepts a bare percentage or {"progress": <percent>}
+func parseLoadingProgress(body []byte) (int32, error) {
+	var progress float64
+	var payload struct {
+		Progress *float64 `json:"progress"`
+	}
+	if err := json.Unmarshal(body, &payload); err == nil && payload.Progress != nil {
+		progress = *payload.Progress
+	} else if v, err := strconv.ParseFloat(strings.TrimSpace(string(body)), 64); err == nil {
+		progress = v
+	} else {
+		return 0, fmt.Errorf("unrecognized load progress %q", body)
+	}
+
+	if progress < 0 {
+		progress = 0
+	}
+	if progress > 100 {
+		progress = 100
+	}
+	return int32(progress), nil
+}
@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	modelv1alpha1 "github.com/example/model-operator/api/v1alpha1"
+)
+
+func TestLoadingProgressSurfacedInStatus(t *testing.T) {
+	ms := newTestModelServe("loading")
+	ms.Spec.HealthCheck = &modelv1alpha1.HealthCheckSpec{LoadingEndpoint: "/loading"}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "loading-abc", Namespace: "default", Labels: labelsForModelServe("loading")},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning, PodIP: "10.0.0.5"},
+	}
+	r := newTestReconciler(t, ms, pod)
+
+	progress := int32(42)
+	r.ProgressFetcher = func(_ context.Context, p *corev1.Pod, path string) (int32, error) {
+		if p.Name != "loading-abc" || path != "/loading" {
+			t.Fatalf("unexpected progress fetch from pod %s path %s", p.Name, path)
+		}
+		return progress, nil
+	}
+
+	reconcileUntilStable(t, r, "loading")
+
+	startup := getDeployment(t, r, "loading").Spec.Template.Spec.Containers[0].StartupProbe
+	if startup == nil || startup.HTTPGet == nil || startup.HTTPGet.Path != "/loading" {
+		t.Fatalf("expected a startup probe on /loading, got %+v", startup)
+	}
+
+	key := types.NamespacedName{Name: "loading", Namespace: "default"}
+	if err := r.Get(context.Background(), key, ms); err != nil {
+		t.Fatal(err)
+	}
+	if ms.Status.LoadingProgress != 42 || ms.Status.Message != "Loading model 42%" {
+		t.Fatalf("expected 42%% load progress in status, got %d (%q)", ms.Status.LoadingProgress, ms.Status.Message)
+	}
+
+	// The next poll picks up further progress
+	progress = 87
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Get(context.Background(), key, ms); err != nil {
+		t.Fatal(err)
+	}
+	if ms.Status.LoadingProgress != 87 {
+		t.Fatalf("expected load progress to advance to 87, got %d", ms.Status.LoadingProgress)
+	}
+}
+
+func TestParseLoadingProgress(t *testing.T) {
+	tests := []struct {
+		body    string
+		want    int32
+		wantErr bool
+	}{
+		{body: `{"progress": 42}`, want: 42},
+		{body: "63.5\n", want: 63},
+		{body: `{"progress": 120}`, want: 100},
+		{body: "loading", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseLoadingProgress([]byte(tt.body))
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseLoadingProgress(%q) = %d, %v; want %d, error %v", tt.body, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	modelv1alpha1 "github.com/example/model-operator/api/v1alpha1"
+)
+
+// memoryMetric is the gauge the monitor sidecar reports the server memory in
+const memoryMetric = "model_memory_mb"
+
+// MemoryFetcher returns the memory in MB a model pod currently uses
+type MemoryFetcher func(ctx context.Context, pod *corev1.Pod) (int32, error)
+
+// memoryUsage returns the highest memory usage of the ready model pods. It
+// reports false when no pod answered.
+func (r *ModelServeReconciler) memoryUsage(ctx context.Context, m *modelv1alpha1.ModelServe) (int32, bool) {
+	fetch := r.MemoryFetcher
+	if fetch == nil {
+		fetch = fetchMemoryUsage
+	}
+
+	podList := &corev1.PodList{}
+	if err := r.List(ctx, podList, client.InNamespace(m.Namespace), client.MatchingLabels(labelsForModelServe(m.Name))); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list pods for memory usage")
+		return 0, false
+	}
+
+	var peak int32
+	found := false
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" || !podReady(pod) {
+			continue
+		}
+		used, err := fetch(ctx, pod)
+		if err != nil {
+			log.FromContext(ctx).V(1).Info("Memory usage not available", "Pod", pod.Name, "error", err.Error())
+			continue
+		}
+		if used > peak {
+			peak = used
+		}
+		found = true
+	}
+	return peak, found
+}
+
+// fetchMemoryUsage scrapes the monitor sidecar metrics of the pod
+func fetchMemoryUsage(ctx context.Context, pod *corev1.Pod) (int32, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://%s:%d/metrics", pod.Status.PodIP, metricsPort), nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("sidecar metrics returned %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return 0, err
+	}
+	return parseMemoryUsage(body)
+}
+
+// parseMemoryUsage reads the memory gauge from Prometheus text format
+func parseMemoryUsage(body []byte) (int32, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		name := fields[0]
+		if i := strings.IndexByte(name, '{'); i >= 0 {
+			name = name[:i]
+		}
+		if name != memoryMetric {
+			continue
+		}
+
+		v, err := strconv.ParseFloat(fields[len(fields)-1], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid value for %s: %v", name, err)
+		}
+		return int32(v), nil
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, fmt.Errorf("no %s metric found", memoryMetric)
+}
@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPeakMemorySurfacedInStatus(t *testing.T) {
+	ms := newTestModelServe("memory")
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "memory-a", Namespace: "default", Labels: labelsForModelServe("memory")},
+		Status: corev1.PodStatus{
+			Phase:      corev1.PodRunning,
+			PodIP:      "10.0.0.8",
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+		},
+	}
+	r := newTestReconciler(t, ms, pod)
+	r.TokenFetcher = func(context.Context, *corev1.Pod, string) (TokenCounts, error) { return TokenCounts{}, nil }
+	used := int32(3100)
+	r.MemoryFetcher = func(context.Context, *corev1.Pod) (int32, error) { return used, nil }
+	reconcileUntilStable(t, r, "memory")
+
+	dep := getDeployment(t, r, "memory")
+	dep.Status.AvailableReplicas = 1
+	if err := r.Update(context.Background(), dep); err != nil {
+		t.Fatal(err)
+	}
+	reconcileUntilStable(t, r, "memory")
+	if peak := getModelServe(t, r, "memory").Status.PeakMemoryMB; peak != 3100 {
+		t.Fatalf("expected peak memory 3100MB, got %d", peak)
+	}
+
+	// Lower usage later keeps the peak
+	used = 2000
+	reconcileUntilStable(t, r, "memory")
+	if peak := getModelServe(t, r, "memory").Status.PeakMemoryMB; peak != 3100 {
+		t.Fatalf("expected the peak to be kept, got %d", peak)
+	}
+}
+
+func TestParseMemoryUsage(t *testing.T) {
+	body := `# TYPE model_memory_mb gauge
+model_memory_mb{server_uuid="abc",model_name="llama"} 2048
+# TYPE model_cpu_percent gauge
+model_cpu_percent{server_uuid="abc",model_name="llama"} 12.5
+`
+	got, err := parseMemoryUsage([]byte(body))
+	if err != nil || got != 2048 {
+		t.Fatalf("expected 2048MB, got %d (%v)", got, err)
+	}
+
+	if _, err := parseMemoryUsage([]byte("model_cpu_percent 1\n")); err == nil {
+		t.Fatal("expected an error without the memory gauge")
+	}
+}
@@ -0,0 +1,237 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	modelv1alpha1 "github.com/example/model-operator/api/v1alpha1"
+)
+
+// sharedCache reports whether the model is served from a shared cache PVC
+func sharedCache(m *modelv1alpha1.ModelServe) bool {
+	return m.Spec.Storage != nil && m.Spec.Storage.SharedClaimName != ""
+}
+
+// modelFileName returns the file name of the model inside /models.
+// Cached files are named after the MinIO object so every ModelServe sharing
+// the cache entry agrees on the name.
+func modelFileName(m *modelv1alpha1.ModelServe) string {
+	if sharedCache(m) {
+		_, _, objectPath := minioLocation(m)
+		return path.Base(objectPath)
+	}
+	return m.VariantFileName()
+}
+
+// cacheKey identifies a cached model by its MinIO location.
+// ModelServes with the same key share a single download.
+func cacheKey(m *modelv1alpha1.ModelServe) string {
+	endpoint, bucket, objectPath := minioLocation(m)
+	sum := sha256.Sum256([]byte(endpoint + "/" + bucket + "/" + objectPath))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// cacheResourceName is the name of both the lock ConfigMap and the download Job
+func cacheResourceName(m *modelv1alpha1.ModelServe) string {
+	return "modelcache-" + cacheKey(m)
+}
+
+// useSharedCache switches the pod template from a per-pod download to the shared cache entry
+func useSharedCache(dep *appsv1.Deployment, m *modelv1alpha1.ModelServe) {
+	podSpec := &dep.Spec.Template.Spec
+
+	// The download Job fills the cache, so pods no longer download themselves
+	initContainers := podSpec.InitContainers[:0]
+	for _, c := range podSpec.InitContainers {
+		if c.Name != "download-model" {
+			initContainers = append(initContainers, c)
+		}
+	}
+	podSpec.InitContainers = initContainers
+
+	for i := range podSpec.Volumes {
+		if podSpec.Volumes[i].Name == "model-volume" {
+			podSpec.Volumes[i].VolumeSource = corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: m.Spec.Storage.SharedClaimName,
+				},
+			}
+		}
+	}
+
+	// Each cache entry lives in its own directory of the claim
+	for _, containers := range [][]corev1.Container{podSpec.InitContainers, podSpec.Containers} {
+		for i := range containers {
+			for j := range containers[i].VolumeMounts {
+				if containers[i].VolumeMounts[j].Name == "model-volume" {
+					containers[i].VolumeMounts[j].SubPath = cacheKey(m)
+				}
+			}
+		}
+	}
+}
+
+// ensureSharedCache makes sure exactly one download Job fills the shared cache
+// entry of the model. The first ModelServe to create the lock ConfigMap owns
+// the download; the others wait for its Job to complete. It returns whether
+// the cache entry is ready and a status message while it is not.
+func (r *ModelServeReconciler) ensureSharedCache(ctx context.Context, m *modelv1alpha1.ModelServe) (bool, string, error) {
+	name := cacheResourceName(m)
+
+	holder, err := r.acquireCacheLock(ctx, m)
+	if err != nil {
+		return false, "", err
+	}
+
+	job := &batchv1.Job{}
+	err = r.Get(ctx, types.NamespacedName{Name: name, Namespace: m.Namespace}, job)
+	if err != nil && !errors.IsNotFound(err) {
+		return false, "", err
+	}
+
+	if errors.IsNotFound(err) {
+		if holder != m.Name {
+			return false, fmt.Sprintf("Waiting for %s to download the model into the shared cache", holder), nil
+		}
+		job = r.jobForSharedCache(m)
+		if err := ctrl.SetControllerReference(m, job, r.Scheme); err != nil {
+			return false, "", err
+		}
+		if err := r.Create(ctx, job); err != nil && !errors.IsAlreadyExists(err) {
+			return false, "", err
+		}
+		return false, "Downloading model into the shared cache", nil
+	}
+
+	for _, cond := range job.Status.Conditions {
+		if cond.Type == batchv1.JobFailed && cond.Status == corev1.ConditionTrue {
+			return false, "", fmt.Errorf("shared cache download job %s failed: %s", name, cond.Message)
+		}
+	}
+	if job.Status.Succeeded > 0 {
+		return true, "", nil
+	}
+	return false, "Downloading model into the shared cache", nil
+}
+
+// acquireCacheLock creates the lock ConfigMap for the cache entry if it does
+// not exist yet and returns the name of the ModelServe holding it. The API
+// server guarantees only one concurrent create succeeds.
+func (r *ModelServeReconciler) acquireCacheLock(ctx context.Context, m *modelv1alpha1.ModelServe) (string, error) {
+	_, bucket, objectPath := minioLocation(m)
+	lock := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cacheResourceName(m),
+			Namespace: m.Namespace,
+			Labels:    map[string]string{"app": "model-cache"},
+		},
+		Data: map[string]string{
+			"holder": m.Name,
+			"object": bucket + "/" + objectPath,
+		},
+	}
+	// The lock is released when its holder is deleted
+	if err := ctrl.SetControllerReference(m, lock, r.Scheme); err != nil {
+		return "", err
+	}
+
+	err := r.Create(ctx, lock)
+	if err == nil {
+		return m.Name, nil
+	}
+	if !errors.IsAlreadyExists(err) {
+		return "", err
+	}
+
+	existing := &corev1.ConfigMap{}
+	if err := r.Get(ctx, types.NamespacedName{Name: lock.Name, Namespace: lock.Namespace}, existing); err != nil {
+		return "", err
+	}
+	return existing.Data["holder"], nil
+}
+
+// jobForSharedCache returns the Job downloading the model into the shared cache
+func (r *ModelServeReconciler) jobForSharedCache(m *modelv1alpha1.ModelServe) *batchv1.Job {
+	return downloadJob(m, cacheResourceName(m), map[string]string{"app": "model-cache"},
+		m.Spec.Storage.SharedClaimName, path.Join(cacheKey(m), modelFileName(m)))
+}
+
+// downloadJob returns a Job downloading the model from MinIO to dest inside
+// the given claim. The file is written under a temporary name first so a pod
+// never sees a partially downloaded model, and an existing file is kept.
+func downloadJob(m *modelv1alpha1.ModelServe, name string, jobLabels map[string]string, claimName, dest string) *batchv1.Job {
+	endpoint, bucket, objectPath := minioLocation(m)
+	dest = path.Join("/models", dest)
+	backoffLimit := int32(3)
+
+	fetch := fmt.Sprintf(`echo "Configuring MinIO client..."
+mc alias set minio http://%[1]s $MINIO_ACCESS_KEY $MINIO_SECRET_KEY
+
+echo "Downloading model from MinIO..."
+mc cp minio/%[2]s/%[3]s %[4]s.partial`, endpoint, bucket, objectPath, dest)
+	if custom, ok := customDownloadScript(m, dest+".partial"); ok {
+		fetch = custom
+	}
+	// Verify before the rename so a corrupt file never becomes the shared copy
+	fetch += downloadVerification(m, dest+".partial")
+	fetch += fmt.Sprintf("\nmv %[1]s.partial %[1]s", dest)
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: m.Namespace,
+			Labels:    jobLabels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: jobLabels},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyOnFailure,
+					Containers: []corev1.Container{{
+						Name:    "download-model",
+						Image:   downloadImage(m),
+						Command: []string{"/bin/sh", "-c"},
+						Args: []string{fmt.Sprintf(`
+set -e
+if [ -f %[1]s ]; then
+  echo "Model already downloaded"
+  exit 0
+fi
+mkdir -p $(dirname %[1]s)
+
+%[2]s
+
+echo "Model downloaded successfully"
+`, dest, fetch)},
+						Env: downloadEnv(m),
+						VolumeMounts: []corev1.VolumeMount{
+							{Name: "model-volume", MountPath: "/models"},
+						},
+					}},
+					Volumes: []corev1.Volume{{
+						Name: "model-volume",
+						VolumeSource: corev1.VolumeSource{
+							PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+								ClaimName: claimName,
+							},
+						},
+					}},
+				},
+			},
+		},
+	}
+	applySecurityProfile(&job.Spec.Template.Spec, m)
+	return job
+}
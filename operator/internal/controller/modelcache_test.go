@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	modelv1alpha1 "github.com/example/model-operator/api/v1alpha1"
+)
+
+func TestSharedCacheRunsSingleDownloadJob(t *testing.T) {
+	first := newTestModelServe("cache-a")
+	second := newTestModelServe("cache-b")
+	for _, ms := range []*modelv1alpha1.ModelServe{first, second} {
+		ms.Spec.MinIOPath = "models/shared.gguf"
+		ms.Spec.Storage = &modelv1alpha1.StorageSpec{SharedClaimName: "model-cache"}
+	}
+	r := newTestReconciler(t, first, second)
+
+	// Race both reconciles for the same cache key
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		for _, name := range []string{"cache-a", "cache-b"} {
+			wg.Add(1)
+			go func(name string) {
+				defer wg.Done()
+				req := ctrl.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: "default"}}
+				if _, err := r.Reconcile(context.Background(), req); err != nil {
+					t.Errorf("reconcile %s: %v", name, err)
+				}
+			}(name)
+		}
+		wg.Wait()
+	}
+
+	jobs := &batchv1.JobList{}
+	if err := r.List(context.Background(), jobs, client.InNamespace("default")); err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs.Items) != 1 {
+		t.Fatalf("expected a single download Job, got %d", len(jobs.Items))
+	}
+	job := jobs.Items[0]
+
+	lock := &corev1.ConfigMap{}
+	if err := r.Get(context.Background(), types.NamespacedName{Name: job.Name, Namespace: "default"}, lock); err != nil {
+		t.Fatalf("get cache lock: %v", err)
+	}
+	holder := lock.Data["holder"]
+	if owner := metav1.GetControllerOf(&job); owner == nil || owner.Name != holder {
+		t.Fatalf("expected the Job to be owned by lock holder %s, got %+v", holder, job.OwnerReferences)
+	}
+
+	// No pods start until the cache is filled
+	for _, name := range []string{"cache-a", "cache-b"} {
+		if err := r.Get(context.Background(), types.NamespacedName{Name: name, Namespace: "default"}, &corev1.Service{}); !errors.IsNotFound(err) {
+			t.Fatalf("expected %s to wait for the shared cache, got %v", name, err)
+		}
+	}
+
+	job.Status.Succeeded = 1
+	if err := r.Update(context.Background(), &job); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"cache-a", "cache-b"} {
+		reconcileUntilStable(t, r, name)
+		podSpec := getDeployment(t, r, name).Spec.Template.Spec
+		if len(podSpec.InitContainers) != 0 {
+			t.Fatalf("expected %s pods to skip the per-pod download, got %+v", name, podSpec.InitContainers)
+		}
+		if pvc := podSpec.Volumes[0].PersistentVolumeClaim; pvc == nil || pvc.ClaimName != "model-cache" {
+			t.Fatalf("expected %s to mount the shared cache claim, got %+v", name, podSpec.Volumes[0])
+		}
+		if mount := podSpec.Containers[0].VolumeMounts[0]; mount.SubPath != cacheKey(first) {
+			t.Fatalf("expected %s to mount cache entry %s, got %+v", name, cacheKey(first), mount)
+		}
+	}
+}
+
+func TestDownloadJobVerifiesBeforeRename(t *testing.T) {
+	cases := map[string]modelv1alpha1.DownloadSpec{
+		"sha256":         {SHA256: "0123abcd"},
+		"checksumObject": {ChecksumObject: "models/shared.gguf.sha256"},
+	}
+	for name, download := range cases {
+		t.Run(name, func(t *testing.T) {
+			ms := newTestModelServe("cache-verify")
+			ms.Spec.Download = &download
+			script := downloadJob(ms, "cache-verify", nil, "model-cache", "shared.gguf").Spec.Template.Spec.Containers[0].Args[0]
+			verify := strings.Index(script, "Verifying model checksum")
+			rename := strings.Index(script, "mv /models/shared.gguf.partial /models/shared.gguf")
+			if verify < 0 || rename < 0 || verify > rename {
+				t.Fatalf("expected the download to be verified before it is renamed into place, got:\n%s", script)
+			}
+			if !strings.Contains(script, "rm -f /models/shared.gguf.partial") {
+				t.Fatalf("expected a mismatching download to be removed, got:\n%s", script)
+			}
+		})
+	}
+}
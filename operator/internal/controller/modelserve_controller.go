@@ -2,23 +2,35 @@ package controller
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	modelv1alpha1 "github.com/example/model-operator/api/v1alpha1"
 )
@@ -26,9 +38,54 @@ import (
 // ModelServeReconciler reconciles a ModelServe object
 type ModelServeReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// ProgressFetcher reads model load progress from a pod; it defaults to
+	// querying spec.healthCheck.loadingEndpoint over HTTP
+	ProgressFetcher ProgressFetcher
+
+	// TokenFetcher reads token counters from a pod; it defaults to scraping
+	// spec.monitoring.metricsEndpoint over HTTP
+	TokenFetcher TokenFetcher
+
+	// MemoryFetcher reads the memory usage of a pod; it defaults to scraping
+	// the monitor sidecar metrics over HTTP
+	MemoryFetcher MemoryFetcher
+
+	// MinIODialer checks whether MinIO accepts connections, to tell transient
+	// download failures from permanent ones; it defaults to a TCP dial
+	MinIODialer MinIODialer
+
+	// APIReader reads single objects straight from the API server, so the
+	// operator does not cache every Secret of the cluster; it defaults to
+	// the client
+	APIReader client.Reader
+}
+
+// apiReader returns the APIReader, or the client when none is set
+func (r *ModelServeReconciler) apiReader() client.Reader {
+	if r.APIReader == nil {
+		return r.Client
+	}
+	return r.APIReader
 }
 
+// metricsPort is the port the monitor sidecar serves its Prometheus metrics on
+const metricsPort = 9090
+
+// configHashAnnotation is set on the pod template so that a change to the
+// mounted ConfigMap content rolls the Deployment
+const configHashAnnotation = "model.example.com/config-hash"
+
+// templateHashAnnotation records the pod template a Deployment was last
+// written with, so that any change of the desired template rolls it. The
+// stored template carries API server defaults and cannot be compared directly.
+const templateHashAnnotation = "model.example.com/template-hash"
+
+// gpuSharingGroupLabel is set on model pods sharing GPUs with their group
+const gpuSharingGroupLabel = "model.example.com/gpu-sharing-group"
+
 // Environment variable defaults
 func getEnvOrDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -40,12 +97,23 @@ func getEnvOrDefault(key, defaultValue string) string {
 //+kubebuilder:rbac:groups=model.example.com,resources=modelserves,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=model.example.com,resources=modelserves/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=model.example.com,resources=modelserves/finalizers,verbs=update
+//+kubebuilder:rbac:groups=model.example.com,resources=modelserveclasses,verbs=get;list;watch
 //+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
-//+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 //+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=serviceaccounts,verbs=get;list;watch;create
+//+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles;rolebindings,verbs=get;list;watch;create
 //+kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=traefik.containo.us,resources=middlewares,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=traefik.containo.us,resources=traefikservices;ingressroutes,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -62,44 +130,215 @@ func (r *ModelServeReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, err
 	}
 
-	// Update status to Pending if not set
+	// Update status to Pending if not set. A conflict re-reads the object, so
+	// the rest of the reconcile continues from its latest version.
 	if modelServe.Status.Phase == "" {
-		modelServe.Status.Phase = "Pending"
-		modelServe.Status.Message = "Initializing model server"
-		if err := r.Status().Update(ctx, modelServe); err != nil {
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			if err := r.Get(ctx, req.NamespacedName, modelServe); err != nil {
+				return err
+			}
+			if modelServe.Status.Phase != "" {
+				return nil
+			}
+			modelServe.Status.Phase = "Pending"
+			modelServe.Status.Message = "Initializing model server"
+			return r.updateStatus(ctx, modelServe)
+		})
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		if err != nil {
 			l.Error(err, "Failed to update initial status")
 			return ctrl.Result{}, err
 		}
 	}
 
+	// Fill what the spec leaves open from the ModelServeClass
+	if waiting, result, err := r.applyClass(ctx, modelServe); err != nil || waiting {
+		if err != nil {
+			l.Error(err, "Failed to apply ModelServeClass", "ModelServeClass", modelServe.Spec.ClassName)
+		}
+		return result, err
+	}
+
+	// Retry downloads that failed while MinIO was unavailable
+	if waiting, result, err := r.retryTransientFailure(ctx, modelServe); err != nil || waiting {
+		if err != nil {
+			l.Error(err, "Failed to retry download")
+		}
+		return result, err
+	}
+
+	// Fail early instead of creating pods that cannot read the MinIO credentials
+	if missing, result, err := r.checkCredentials(ctx, modelServe); err != nil || missing {
+		if err != nil {
+			l.Error(err, "Failed to check MinIO credentials")
+		}
+		return result, err
+	}
+
 	// Create StripPrefix middleware for Traefik
 	if err := r.createStripPrefixMiddleware(ctx, modelServe); err != nil {
 		l.Error(err, "Failed to create StripPrefix middleware")
 		return ctrl.Result{}, err
 	}
 
+	// Fan the model path out to the weighted backends
+	if err := r.reconcileRouter(ctx, modelServe); err != nil {
+		l.Error(err, "Failed to reconcile router")
+		return ctrl.Result{}, err
+	}
+
+	// Restrict model pod traffic before any pod is started
+	if err := r.reconcileNetworkPolicy(ctx, modelServe); err != nil {
+		l.Error(err, "Failed to reconcile NetworkPolicy")
+		return ctrl.Result{}, err
+	}
+
+	// A size that is not a quantity can never be claimed
+	if invalid, err := r.checkStorageSize(ctx, modelServe); err != nil || invalid {
+		if err != nil {
+			l.Error(err, "Failed to update ModelServe status")
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Create the persistent model volume before the pods that mount it
+	if err := r.reconcileModelClaim(ctx, modelServe); err != nil {
+		l.Error(err, "Failed to reconcile model PersistentVolumeClaim")
+		return ctrl.Result{}, err
+	}
+
+	// Download a lazy model once its activator received the first request
+	if err := r.reconcileActivation(ctx, modelServe); err != nil {
+		l.Error(err, "Failed to activate lazily downloaded model")
+		return r.failDownload(ctx, modelServe, err)
+	}
+
+	// Record when the cluster forces a different server image
+	if note := imageOverrideNote(modelServe); modelServe.Status.ImageOverride != note {
+		modelServe.Status.ImageOverride = note
+		if err := r.updateStatus(ctx, modelServe); err != nil {
+			l.Error(err, "Failed to record image override")
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Hash the mounted configuration so content changes roll the pods
+	configHash, err := r.configFileHash(ctx, modelServe)
+	if err != nil {
+		l.Error(err, "Failed to read config file ConfigMap")
+		return ctrl.Result{}, err
+	}
+
+	// The activator pods need their script and permissions in the namespace
+	if awaitingActivation(modelServe) {
+		if err := r.ensureActivator(ctx, modelServe); err != nil {
+			l.Error(err, "Failed to create the activator")
+			return ctrl.Result{}, err
+		}
+	}
+
 	// Define Deployment
 	dep := r.deploymentForModelServe(modelServe)
+	if configHash != "" {
+		dep.Spec.Template.Annotations[configHashAnnotation] = configHash
+	}
+	if awaitingActivation(modelServe) {
+		useActivator(dep, modelServe)
+	}
+	setTemplateHash(dep)
+
+	// Report pod labels dropped for the Service selector
+	if err := r.checkPodSelector(ctx, modelServe); err != nil {
+		l.Error(err, "Failed to record ignored pod labels")
+		return ctrl.Result{}, err
+	}
+
+	// Run replacements ahead of evictions from terminating nodes
+	surge, err := r.evictionSurge(ctx, modelServe)
+	if err != nil {
+		l.Error(err, "Failed to check model pods for terminating nodes")
+		return ctrl.Result{}, err
+	}
+	if surge > 0 {
+		replicas := *dep.Spec.Replicas + surge
+		dep.Spec.Replicas = &replicas
+	}
+
+	// Keep a failed pod for debugging instead of letting it be replaced
+	retained, err := r.reconcileRetainedPod(ctx, modelServe)
+	if err != nil {
+		l.Error(err, "Failed to retain failed model pod")
+		return ctrl.Result{}, err
+	}
+	if retained {
+		replicas := int32(0)
+		dep.Spec.Replicas = &replicas
+		surge = 0
+	}
 
 	// Check if Deployment exists
 	found := &appsv1.Deployment{}
 	err = r.Get(ctx, types.NamespacedName{Name: dep.Name, Namespace: dep.Namespace}, found)
 	if err != nil && errors.IsNotFound(err) {
+		// Check the model on CPU before starting the expensive pods
+		if modelServe.Spec.Preflight {
+			passed, message, err := r.ensurePreflight(ctx, modelServe)
+			if err != nil {
+				l.Error(err, "Model preflight failed")
+				return r.failDownload(ctx, modelServe, err)
+			}
+			if !passed {
+				if modelServe.Status.Phase != "Preflighting" || modelServe.Status.Message != message {
+					modelServe.Status.Phase = "Preflighting"
+					modelServe.Status.Message = message
+					if err := r.updateStatus(ctx, modelServe); err != nil {
+						return ctrl.Result{}, err
+					}
+				}
+				return ctrl.Result{}, nil
+			}
+		}
+
+		// Wait for the shared cache to hold the model before starting any pod
+		if sharedCache(modelServe) {
+			ready, message, err := r.ensureSharedCache(ctx, modelServe)
+			if err != nil {
+				l.Error(err, "Failed to prepare shared model cache")
+				return r.failDownload(ctx, modelServe, err)
+			}
+			if !ready {
+				if modelServe.Status.Phase != "Downloading" || modelServe.Status.Message != message {
+					modelServe.Status.Phase = "Downloading"
+					modelServe.Status.Message = message
+					if err := r.updateStatus(ctx, modelServe); err != nil {
+						return ctrl.Result{}, err
+					}
+				}
+				return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+			}
+		}
+
 		l.Info("Creating a new Deployment", "Deployment.Namespace", dep.Namespace, "Deployment.Name", dep.Name)
-		
-		// Update status to Downloading
+
+		// Update status to Downloading, or Standby until a lazy model is requested
 		modelServe.Status.Phase = "Downloading"
 		modelServe.Status.Message = "Downloading model from MinIO"
-		if err := r.Status().Update(ctx, modelServe); err != nil {
+		if awaitingActivation(modelServe) {
+			modelServe.Status.Phase = "Standby"
+			modelServe.Status.Message = "Waiting for the first request to download the model"
+		}
+		if err := r.updateStatus(ctx, modelServe); err != nil {
 			l.Error(err, "Failed to update status to Downloading")
 		}
-		
+
 		err = r.Create(ctx, dep)
 		if err != nil {
 			l.Error(err, "Failed to create new Deployment", "Deployment.Namespace", dep.Namespace, "Deployment.Name", dep.Name)
 			modelServe.Status.Phase = "Failed"
 			modelServe.Status.Message = fmt.Sprintf("Failed to create deployment: %v", err)
-			r.Status().Update(ctx, modelServe)
+			r.updateStatus(ctx, modelServe)
 			return ctrl.Result{}, err
 		}
 		// Deployment created successfully - return and requeue
@@ -109,6 +348,62 @@ func (r *ModelServeReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, err
 	}
 
+	// Keep the replica count in sync with the spec and any eviction surge,
+	// and the progress deadline and minimum ready time with the spec
+	replicasChanged := found.Spec.Replicas == nil || *found.Spec.Replicas != *dep.Spec.Replicas
+	deadlineChanged := found.Spec.ProgressDeadlineSeconds == nil || *found.Spec.ProgressDeadlineSeconds != *dep.Spec.ProgressDeadlineSeconds ||
+		found.Spec.MinReadySeconds != dep.Spec.MinReadySeconds
+	annotationsChanged := syncAnnotations(found, deploymentAnnotationsForModelServe(modelServe))
+	if replicasChanged || deadlineChanged || annotationsChanged {
+		if replicasChanged && surge > 0 {
+			r.Recorder.Eventf(modelServe, corev1.EventTypeNormal, "EvictionPreScale",
+				"Starting %d replacement pod(s) ahead of node termination", surge)
+		}
+		found.Spec.Replicas = dep.Spec.Replicas
+		found.Spec.ProgressDeadlineSeconds = dep.Spec.ProgressDeadlineSeconds
+		found.Spec.MinReadySeconds = dep.Spec.MinReadySeconds
+		if err := r.Update(ctx, found); err != nil {
+			l.Error(err, "Failed to scale Deployment", "Deployment.Namespace", found.Namespace, "Deployment.Name", found.Name)
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	if err := r.finishRollout(ctx, modelServe, found); err != nil {
+		l.Error(err, "Failed to update ModelServe status")
+		return ctrl.Result{}, err
+	}
+
+	// Roll the Deployment whenever the desired pod template changed
+	if found.Annotations[templateHashAnnotation] != dep.Annotations[templateHashAnnotation] {
+		// Fleet-wide changes roll a limited number of models at a time
+		started, err := r.startRollout(ctx, modelServe)
+		if err != nil {
+			l.Error(err, "Failed to update ModelServe status")
+			return ctrl.Result{}, err
+		}
+		if !started {
+			l.Info("Deferring rollout, too many rollouts in progress", "Deployment.Namespace", found.Namespace, "Deployment.Name", found.Name)
+			return ctrl.Result{RequeueAfter: rolloutDeferInterval}, nil
+		}
+
+		l.Info("Pod template changed, rolling Deployment", "Deployment.Namespace", found.Namespace, "Deployment.Name", found.Name)
+		found.Spec.Template = dep.Spec.Template
+		mergeAnnotations(found, map[string]string{templateHashAnnotation: dep.Annotations[templateHashAnnotation]})
+		if err := r.Update(ctx, found); err != nil {
+			l.Error(err, "Failed to update Deployment", "Deployment.Namespace", found.Namespace, "Deployment.Name", found.Name)
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	// Heterogeneous replicas run in Deployments of their own
+	groupsAvailable, err := r.reconcileReplicaGroups(ctx, modelServe, configHash)
+	if err != nil {
+		l.Error(err, "Failed to reconcile replica groups")
+		return ctrl.Result{}, err
+	}
+
 	// Define Service
 	svc := r.serviceForModelServe(modelServe)
 
@@ -128,30 +423,84 @@ func (r *ModelServeReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, err
 	}
 
-	// Define Ingress
+	// Keep the Service ports and propagated annotations in sync
+	annotationsChanged = syncAnnotations(foundSvc, propagatedAnnotations(modelServe))
+	if !servicePortsMatch(foundSvc.Spec.Ports, svc.Spec.Ports) || annotationsChanged {
+		foundSvc.Spec.Ports = svc.Spec.Ports
+		if err := r.Update(ctx, foundSvc); err != nil {
+			l.Error(err, "Failed to update Service", "Service.Namespace", foundSvc.Namespace, "Service.Name", foundSvc.Name)
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	// Per pod DNS for clients addressing individual replicas
+	if err := r.reconcileHeadlessService(ctx, modelServe); err != nil {
+		l.Error(err, "Failed to reconcile headless Service")
+		return ctrl.Result{}, err
+	}
+
+	// Define Ingress, routed to the warmup backend until a model pod is available
 	ing := r.ingressForModelServe(modelServe)
+	warming := warmingUp(modelServe, found)
+	if warming {
+		if err := r.ensureWarmupBackend(ctx, modelServe.Namespace); err != nil {
+			l.Error(err, "Failed to create warmup backend")
+			return ctrl.Result{}, err
+		}
+		ing.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name = warmupBackendName
+	}
 
 	// Check if Ingress exists
 	foundIng := &networkingv1.Ingress{}
+	routeDeferred := false
 	err = r.Get(ctx, types.NamespacedName{Name: ing.Name, Namespace: ing.Namespace}, foundIng)
 	if err != nil && errors.IsNotFound(err) {
-		l.Info("Creating a new Ingress", "Ingress.Namespace", ing.Namespace, "Ingress.Name", ing.Name)
-		err = r.Create(ctx, ing)
-		if err != nil {
-			l.Error(err, "Failed to create new Ingress", "Ingress.Namespace", ing.Namespace, "Ingress.Name", ing.Name)
-			return ctrl.Result{}, err
+		if modelServe.Spec.RouteWhenReady && !modelServing(modelServe, found) {
+			// Without a route clients never reach a model that is still starting
+			l.Info("Deferring Ingress until the model is running", "Ingress.Namespace", ing.Namespace, "Ingress.Name", ing.Name)
+			routeDeferred = true
+		} else {
+			l.Info("Creating a new Ingress", "Ingress.Namespace", ing.Namespace, "Ingress.Name", ing.Name)
+			err = r.Create(ctx, ing)
+			if err != nil {
+				l.Error(err, "Failed to create new Ingress", "Ingress.Namespace", ing.Namespace, "Ingress.Name", ing.Name)
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{Requeue: true}, nil
 		}
-		return ctrl.Result{Requeue: true}, nil
 	} else if err != nil {
 		l.Error(err, "Failed to get Ingress")
 		return ctrl.Result{}, err
 	}
 
+	// Keep the routes in sync, e.g. when the model leaves the warmup backend or
+	// its path changed, and the middleware chain and propagated annotations
+	annotationsChanged = !routeDeferred && syncAnnotations(foundIng, ing.Annotations)
+	if !routeDeferred && (!equality.Semantic.DeepEqual(foundIng.Spec.Rules, ing.Spec.Rules) ||
+		!equality.Semantic.DeepEqual(foundIng.Spec.IngressClassName, ing.Spec.IngressClassName) || annotationsChanged) {
+		foundIng.Spec.Rules = ing.Spec.Rules
+		foundIng.Spec.IngressClassName = ing.Spec.IngressClassName
+		if err := r.Update(ctx, foundIng); err != nil {
+			l.Error(err, "Failed to update Ingress", "Ingress.Namespace", foundIng.Namespace, "Ingress.Name", foundIng.Name)
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{Requeue: true}, nil
+	}
+
 	// Update Status based on deployment state
 	needsStatusUpdate := false
-	
-	if found.Status.AvailableReplicas != modelServe.Status.AvailableReplicas {
-		modelServe.Status.AvailableReplicas = found.Status.AvailableReplicas
+
+	// Replicas of the base Deployment and the replica groups
+	available := found.Status.AvailableReplicas + groupsAvailable
+	if available != modelServe.Status.AvailableReplicas {
+		modelServe.Status.AvailableReplicas = available
+		needsStatusUpdate = true
+	}
+
+	// Attribution recorded by the defaulting webhook
+	if by := modelServe.Annotations[modelv1alpha1.LastModifiedByAnnotation]; modelServe.Status.LastModifiedBy != by {
+		modelServe.Status.LastModifiedBy = by
 		needsStatusUpdate = true
 	}
 
@@ -161,67 +510,164 @@ func (r *ModelServeReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		needsStatusUpdate = true
 	}
 
+	// Middlewares of the route as present on the Ingress
+	var middlewares []string
+	if !routeDeferred {
+		middlewares = appliedMiddlewares(foundIng)
+	}
+	if !equality.Semantic.DeepEqual(modelServe.Status.AppliedMiddlewares, middlewares) {
+		modelServe.Status.AppliedMiddlewares = middlewares
+		needsStatusUpdate = true
+	}
+
+	// Pod selector for the scale subresource
+	if selector := labels.SelectorFromSet(labelsForModelServe(modelServe.Name)).String(); modelServe.Status.Selector != selector {
+		modelServe.Status.Selector = selector
+		needsStatusUpdate = true
+	}
+
 	// Update gateway URL
-	gatewayURL := fmt.Sprintf("http://localhost/%s", modelServe.Name)
+	gatewayURL := r.gatewayURL(ctx, modelServe)
 	if modelServe.Status.GatewayURL != gatewayURL {
 		modelServe.Status.GatewayURL = gatewayURL
 		needsStatusUpdate = true
 	}
 
-	// Update phase based on replicas
-	if found.Status.AvailableReplicas > 0 {
+	// Track a running pod and drop the reference once the model scaled to zero
+	// or its pods died
+	if podName, err := r.runningPodName(ctx, modelServe); err == nil && modelServe.Status.PodName != podName {
+		modelServe.Status.PodName = podName
+		if podName == "" && available == 0 {
+			modelServe.Status.StartedAt = nil
+		}
+		needsStatusUpdate = true
+	}
+
+	// Update phase based on replicas. A ready activator does not serve the model.
+	result := ctrl.Result{}
+	if cond := deploymentCondition(found, appsv1.DeploymentProgressing); cond != nil && cond.Reason == "ProgressDeadlineExceeded" {
+		// A rollout stuck past its deadline will not recover on its own
+		message := fmt.Sprintf("Rollout exceeded its progress deadline: %s", cond.Message)
+		if modelServe.Status.Phase != "Failed" || modelServe.Status.Message != message {
+			modelServe.Status.Phase = "Failed"
+			modelServe.Status.Message = message
+			needsStatusUpdate = true
+		}
+	} else if awaitingActivation(modelServe) {
+		if modelServe.Annotations[activationRequestedAnnotation] == "" && modelServe.Status.Phase != "Standby" {
+			modelServe.Status.Phase = "Standby"
+			modelServe.Status.Message = "Waiting for the first request to download the model"
+			needsStatusUpdate = true
+		}
+	} else if available > 0 {
 		if modelServe.Status.Phase != "Running" {
 			modelServe.Status.Phase = "Running"
 			modelServe.Status.Message = "Model server is running"
 			now := metav1.NewTime(time.Now())
 			modelServe.Status.StartedAt = &now
+			// A later outage backs off from the start again
+			modelServe.Status.DownloadRetries = 0
+			if loadingEndpoint(modelServe) != "" {
+				modelServe.Status.LoadingProgress = 100
+			}
 			needsStatusUpdate = true
 		}
-		
-		// Try to get pod name
-		podList := &corev1.PodList{}
-		listOpts := []client.ListOption{
-			client.InNamespace(modelServe.Namespace),
-			client.MatchingLabels(labelsForModelServe(modelServe.Name)),
-		}
-		if err := r.List(ctx, podList, listOpts...); err == nil && len(podList.Items) > 0 {
-			for _, pod := range podList.Items {
-				if pod.Status.Phase == corev1.PodRunning {
-					if modelServe.Status.PodName != pod.Name {
-						modelServe.Status.PodName = pod.Name
-						needsStatusUpdate = true
-					}
-					break
+
+		// Token totals reported by the serving pods, refreshed periodically
+		if counts, ok := r.tokenCounts(ctx, modelServe); ok {
+			if modelServe.Status.PromptTokens != counts.Prompt || modelServe.Status.GeneratedTokens != counts.Generated {
+				modelServe.Status.PromptTokens = counts.Prompt
+				modelServe.Status.GeneratedTokens = counts.Generated
+				needsStatusUpdate = true
+			}
+		}
+
+		// Remember the highest usage, so lowering memoryLimit below it warns
+		if used, ok := r.memoryUsage(ctx, modelServe); ok && used > modelServe.Status.PeakMemoryMB {
+			modelServe.Status.PeakMemoryMB = used
+			needsStatusUpdate = true
+		}
+		result.RequeueAfter = tokenPollInterval
+	} else {
+		if modelServe.Status.Phase != "Downloading" && modelServe.Status.Phase != "Failed" {
+			modelServe.Status.Phase = "Pending"
+			modelServe.Status.Message = "Waiting for pod to be ready"
+			needsStatusUpdate = true
+		}
+
+		// Surface how far the server got loading the model and keep polling
+		if loadingEndpoint(modelServe) != "" {
+			if progress, ok := r.loadingProgress(ctx, modelServe); ok {
+				message := fmt.Sprintf("Loading model %d%%", progress)
+				if modelServe.Status.LoadingProgress != progress || modelServe.Status.Message != message {
+					modelServe.Status.LoadingProgress = progress
+					modelServe.Status.Message = message
+					needsStatusUpdate = true
 				}
 			}
+			result.RequeueAfter = loadingPollInterval
 		}
-	} else if modelServe.Status.Phase != "Downloading" && modelServe.Status.Phase != "Failed" {
-		modelServe.Status.Phase = "Pending"
-		modelServe.Status.Message = "Waiting for pod to be ready"
-		needsStatusUpdate = true
+	}
+
+	// Check back for available pods to leave the warmup backend or create the route
+	if (warming || routeDeferred) && result.RequeueAfter == 0 {
+		result.RequeueAfter = 10 * time.Second
+	}
+
+	// Pods are not watched, so check back for the deletion of a retained pod
+	if retained && result.RequeueAfter == 0 {
+		result.RequeueAfter = 30 * time.Second
 	}
 
 	if needsStatusUpdate {
-		err = r.Status().Update(ctx, modelServe)
+		err = r.updateStatus(ctx, modelServe)
 		if err != nil {
 			l.Error(err, "Failed to update ModelServe status")
 			return ctrl.Result{}, err
 		}
 	}
 
-	return ctrl.Result{}, nil
+	return result, nil
 }
 
-// createStripPrefixMiddleware creates a Traefik StripPrefix middleware for the model
+// runningPodName returns the name of a running model pod, or an empty string
+// when none is left
+func (r *ModelServeReconciler) runningPodName(ctx context.Context, m *modelv1alpha1.ModelServe) (string, error) {
+	podList := &corev1.PodList{}
+	listOpts := []client.ListOption{
+		client.InNamespace(m.Namespace),
+		client.MatchingLabels(labelsForModelServe(m.Name)),
+	}
+	if err := r.List(ctx, podList, listOpts...); err != nil {
+		return "", err
+	}
+	for _, pod := range podList.Items {
+		if pod.Status.Phase == corev1.PodRunning && pod.DeletionTimestamp == nil {
+			return pod.Name, nil
+		}
+	}
+	return "", nil
+}
+
+// createStripPrefixMiddleware creates a Traefik StripPrefix middleware for the
+// model, or updates it when the route path changed
 func (r *ModelServeReconciler) createStripPrefixMiddleware(ctx context.Context, m *modelv1alpha1.ModelServe) error {
 	// Create StripPrefix middleware using unstructured object since we may not have Traefik CRDs imported
 	middleware := &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      m.Name + "-stripprefix",
 			Namespace: m.Namespace,
-			Labels:    labelsForModelServe(m.Name),
 		},
-		Data: map[string]string{
+	}
+
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, middleware, func() error {
+		if middleware.Labels == nil {
+			middleware.Labels = map[string]string{}
+		}
+		for k, v := range labelsForModelServe(m.Name) {
+			middleware.Labels[k] = v
+		}
+		middleware.Data = map[string]string{
 			"middleware.yaml": fmt.Sprintf(`
 apiVersion: traefik.containo.us/v1alpha1
 kind: Middleware
@@ -231,17 +677,71 @@ metadata:
 spec:
   stripPrefix:
     prefixes:
-      - /%s
-`, m.Name, m.Namespace, m.Name),
-		},
+      - %s
+`, m.Name, m.Namespace, m.RoutePath()),
+		}
+		return nil
+	})
+	return err
+}
+
+// configFileHash returns a hash of the ConfigMap referenced by spec.configFile,
+// or an empty string when no config file is configured or it does not exist yet
+func (r *ModelServeReconciler) configFileHash(ctx context.Context, m *modelv1alpha1.ModelServe) (string, error) {
+	if m.Spec.ConfigFile == nil {
+		return "", nil
 	}
 
-	found := &corev1.ConfigMap{}
-	err := r.Get(ctx, types.NamespacedName{Name: middleware.Name, Namespace: middleware.Namespace}, found)
-	if err != nil && errors.IsNotFound(err) {
-		return r.Create(ctx, middleware)
+	cm := &corev1.ConfigMap{}
+	err := r.Get(ctx, types.NamespacedName{Name: m.Spec.ConfigFile.ConfigMapName, Namespace: m.Namespace}, cm)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// The kubelet waits for the ConfigMap; the watch requeues us once it exists
+			return "", nil
+		}
+		return "", err
 	}
-	return err
+
+	keys := make([]string, 0, len(cm.Data)+len(cm.BinaryData))
+	for k := range cm.Data {
+		keys = append(keys, k)
+	}
+	for k := range cm.BinaryData {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	for _, k := range keys {
+		h.Write([]byte(k))
+		h.Write([]byte{0})
+		if v, ok := cm.Data[k]; ok {
+			h.Write([]byte(v))
+		} else {
+			h.Write(cm.BinaryData[k])
+		}
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16], nil
+}
+
+// modelServesForConfigMap maps a ConfigMap event to the ModelServes mounting it
+func (r *ModelServeReconciler) modelServesForConfigMap(ctx context.Context, obj client.Object) []reconcile.Request {
+	modelServes := &modelv1alpha1.ModelServeList{}
+	if err := r.List(ctx, modelServes, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list ModelServes for ConfigMap", "ConfigMap", obj.GetName())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, ms := range modelServes.Items {
+		if ms.Spec.ConfigFile != nil && ms.Spec.ConfigFile.ConfigMapName == obj.GetName() {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: ms.Name, Namespace: ms.Namespace},
+			})
+		}
+	}
+	return requests
 }
 
 // deploymentForModelServe returns a modelServe Deployment object with MinIO init container
@@ -252,111 +752,145 @@ func (r *ModelServeReconciler) deploymentForModelServe(m *modelv1alpha1.ModelSer
 		r := int32(1)
 		replicas = &r
 	}
-
-	image := m.Spec.Image
-	if image == "" {
-		image = "ghcr.io/ggerganov/llama.cpp:server"
+	// The warm pool is a floor under whatever scaled the model down
+	if *replicas < m.Spec.WarmPool {
+		warm := m.Spec.WarmPool
+		replicas = &warm
 	}
 
-	// Get MinIO configuration from spec or environment
-	minioEndpoint := m.Spec.MinIOEndpoint
-	if minioEndpoint == "" {
-		minioEndpoint = getEnvOrDefault("MINIO_ENDPOINT", "minio:9000")
-	}
-	
-	minioBucket := m.Spec.MinIOBucket
-	if minioBucket == "" {
-		minioBucket = getEnvOrDefault("MINIO_BUCKET", "inference-models")
-	}
+	image := serverImage(m)
 
-	minioPath := m.Spec.MinIOPath
-	if minioPath == "" {
-		minioPath = "models/" + m.Spec.ModelName
-	}
+	// Get MinIO configuration from spec or environment
+	minioEndpoint, minioBucket, minioPath := minioLocation(m)
 
 	// Memory and CPU limits
-	memoryLimit := m.Spec.MemoryLimit
-	if memoryLimit == 0 {
-		memoryLimit = 4096 // 4GB default
-	}
-	cpuLimit := m.Spec.CPULimit
-	if cpuLimit == 0 {
-		cpuLimit = 2000 // 2 cores default
-	}
+	memoryLimit := serverMemoryLimit(m)
+	cpuLimit := serverCPULimit(m)
 
 	// Parse runtime params if provided
 	llamaArgs := []string{
-		"-m", "/models/" + m.Spec.ModelName,
+		"-m", "/models/" + modelFileName(m),
 		"--host", "0.0.0.0",
 		"--port", "8080",
+		// Token counters for usage accounting
+		"--metrics",
+	}
+	if m.Spec.ContextSize != nil && *m.Spec.ContextSize > 0 {
+		llamaArgs = append(llamaArgs, "--ctx-size", fmt.Sprint(*m.Spec.ContextSize))
+	}
+	if m.Spec.ParallelSlots != nil {
+		llamaArgs = append(llamaArgs, "--parallel", fmt.Sprint(*m.Spec.ParallelSlots))
 	}
 	if m.Spec.RuntimeParams != "" {
 		// Parse additional params
 		extraArgs := strings.Fields(m.Spec.RuntimeParams)
+		if m.Spec.ParallelSlots != nil {
+			extraArgs = dropFlag(extraArgs, "--parallel", "-np")
+		}
 		llamaArgs = append(llamaArgs, extraArgs...)
 	}
 
+	// A persistent volume keeps the model across restarts, so only download it
+	// once, unless it no longer matches its checksum
+	skipIfPresent := ""
+	checksum := ""
+	if m.Spec.Download != nil && m.Spec.Download.SHA256 != "" {
+		checksum = fmt.Sprintf(`echo "%s  /models/%s" | sha256sum -c - > /dev/null`, m.Spec.Download.SHA256, modelFileName(m))
+	}
+	if persistentStorage(m) {
+		if checksum != "" && m.Spec.Download.VerifyOnStart {
+			skipIfPresent = fmt.Sprintf(`if [ -f /models/%[1]s ]; then
+  echo "Verifying model on the persistent volume..."
+  if %[2]s; then
+    echo "Model present and verified"
+    exit 0
+  fi
+  echo "Checksum mismatch, downloading the model again"
+  rm -f /models/%[1]s
+fi
+`, modelFileName(m), checksum)
+		} else {
+			skipIfPresent = fmt.Sprintf(`if [ -f /models/%s ]; then
+  echo "Model already present on the persistent volume"
+  exit 0
+fi
+`, modelFileName(m))
+		}
+	}
+	fetch := fmt.Sprintf(`echo "Configuring MinIO client..."
+mc alias set minio http://%[1]s $MINIO_ACCESS_KEY $MINIO_SECRET_KEY
+
+echo "Downloading model from MinIO..."
+mc cp minio/%[2]s/%[3]s /models/%[4]s`, minioEndpoint, minioBucket, minioPath, modelFileName(m))
+	if custom, ok := customDownloadScript(m, "/models/"+modelFileName(m)); ok {
+		fetch = custom
+	}
+	verifyDownload := downloadVerification(m, "/models/"+modelFileName(m))
+
+	// Give model loads a generous window before a rollout counts as stuck
+	progressDeadline := m.Spec.ProgressDeadlineSeconds
+	if progressDeadline == nil {
+		d := int32(600)
+		progressDeadline = &d
+	}
+
+	// Pods must stay ready for a moment before the model counts as running
+	minReady := m.Spec.MinReadySeconds
+	if minReady == nil {
+		d := int32(5)
+		minReady = &d
+	}
+
+	// Model pods only get an API token when they ask for one
+	automountToken := m.Spec.AutomountServiceAccountToken
+	if automountToken == nil {
+		f := false
+		automountToken = &f
+	}
+
 	shareProcessNamespace := true
 
-	return &appsv1.Deployment{
+	dep := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      m.Name,
-			Namespace: m.Namespace,
-			Labels:    ls,
+			Name:        m.Name,
+			Namespace:   m.Namespace,
+			Labels:      ls,
+			Annotations: withManagedAnnotations(deploymentAnnotationsForModelServe(m)),
 		},
 		Spec: appsv1.DeploymentSpec{
-			Replicas: replicas,
+			Replicas:                replicas,
+			ProgressDeadlineSeconds: progressDeadline,
+			MinReadySeconds:         *minReady,
 			Selector: &metav1.LabelSelector{
 				MatchLabels: ls,
 			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: ls,
+					Labels: podLabelsForModelServe(m),
 					Annotations: map[string]string{
 						"model-uuid": m.Spec.ModelUUID,
 					},
 				},
 				Spec: corev1.PodSpec{
-					ShareProcessNamespace: &shareProcessNamespace,
+					ShareProcessNamespace:        &shareProcessNamespace,
+					AutomountServiceAccountToken: automountToken,
 					// Init container to download model from MinIO
 					InitContainers: []corev1.Container{
 						{
-							Name:  "download-model",
-							Image: "minio/mc:latest",
+							Name:    "download-model",
+							Image:   downloadImage(m),
 							Command: []string{"/bin/sh", "-c"},
 							Args: []string{
 								fmt.Sprintf(`
 set -e
-echo "Configuring MinIO client..."
-mc alias set minio http://%s $MINIO_ACCESS_KEY $MINIO_SECRET_KEY
-
-echo "Downloading model from MinIO..."
-mc cp minio/%s/%s /models/%s
-
+%[1]s
+%[2]s
+%[3]s
 echo "Model downloaded successfully"
 ls -la /models/
-`, minioEndpoint, minioBucket, minioPath, m.Spec.ModelName),
-							},
-							Env: []corev1.EnvVar{
-								{
-									Name: "MINIO_ACCESS_KEY",
-									ValueFrom: &corev1.EnvVarSource{
-										SecretKeyRef: &corev1.SecretKeySelector{
-											LocalObjectReference: corev1.LocalObjectReference{Name: "inference-secrets"},
-											Key:                  "MINIO_ACCESS_KEY",
-										},
-									},
-								},
-								{
-									Name: "MINIO_SECRET_KEY",
-									ValueFrom: &corev1.EnvVarSource{
-										SecretKeyRef: &corev1.SecretKeySelector{
-											LocalObjectReference: corev1.LocalObjectReference{Name: "inference-secrets"},
-											Key:                  "MINIO_SECRET_KEY",
-										},
-									},
-								},
+`, skipIfPresent, fetch, verifyDownload),
 							},
+							Env: downloadEnv(m),
 							VolumeMounts: []corev1.VolumeMount{
 								{Name: "model-volume", MountPath: "/models"},
 							},
@@ -374,6 +908,7 @@ ls -la /models/
 							VolumeMounts: []corev1.VolumeMount{{
 								Name:      "model-volume",
 								MountPath: "/models",
+								ReadOnly:  modelReadOnly(m),
 							}},
 							Resources: corev1.ResourceRequirements{
 								Requests: corev1.ResourceList{
@@ -411,10 +946,18 @@ ls -la /models/
 							Image:   "python:3.9-slim",
 							Command: []string{"/bin/sh", "-c"},
 							Args:    []string{"pip install psycopg2-binary psutil requests && python /scripts/monitor.py"},
+							Ports: []corev1.ContainerPort{{
+								ContainerPort: metricsPort,
+								Name:          "metrics",
+							}},
 							Env: []corev1.EnvVar{
 								{Name: "SERVER_UUID", Value: m.Name},
 								{Name: "MODEL_UUID", Value: m.Spec.ModelUUID},
 								{Name: "MODEL_NAME", Value: m.Spec.ModelName},
+								{Name: "METRICS_PORT", Value: fmt.Sprint(metricsPort)},
+								{Name: "SERVER_METRICS_ENDPOINT", Value: serverMetricsEndpoint(m)},
+								{Name: "PROMPT_TOKENS_METRIC", Value: promptTokensMetric},
+								{Name: "GENERATED_TOKENS_METRIC", Value: generatedTokensMetric},
 								{
 									Name: "DATABASE_URL",
 									ValueFrom: &corev1.EnvVarSource{
@@ -442,12 +985,8 @@ ls -la /models/
 					},
 					Volumes: []corev1.Volume{
 						{
-							Name: "model-volume",
-							VolumeSource: corev1.VolumeSource{
-								EmptyDir: &corev1.EmptyDirVolumeSource{
-									SizeLimit: resource.NewQuantity(10*1024*1024*1024, resource.BinarySI), // 10GB
-								},
-							},
+							Name:         "model-volume",
+							VolumeSource: modelVolumeSource(m),
 						},
 						{
 							Name: "monitor-script",
@@ -462,26 +1001,482 @@ ls -la /models/
 			},
 		},
 	}
+
+	// Run the user startup script once the model is in place
+	if m.Spec.StartupScript != "" {
+		podSpec := &dep.Spec.Template.Spec
+		podSpec.InitContainers = append(podSpec.InitContainers, corev1.Container{
+			Name:    "startup-script",
+			Image:   image,
+			Command: []string{"/bin/sh", "-c"},
+			Args:    []string{m.Spec.StartupScript},
+			VolumeMounts: []corev1.VolumeMount{
+				{Name: "model-volume", MountPath: "/models"},
+			},
+		})
+	}
+
+	// Serve the model from the shared cache instead of downloading it per pod
+	if sharedCache(m) {
+		useSharedCache(dep, m)
+	}
+
+	// Request GPUs, MIG slices or time-sliced GPUs; extended resource requests
+	// default to the limit. Only the server container asks for them: the pod
+	// is scheduled once for the larger of its largest init container and the
+	// sum of its containers, so the download neither waits for nor holds a
+	// GPU of its own. GPU nodes are commonly tainted with the resource name,
+	// which the pod tolerates as the ExtendedResourceToleration plugin would.
+	if m.GPUs() > 0 {
+		dep.Spec.Template.Spec.Containers[0].Resources.Limits[gpuResourceName(m)] = *resource.NewQuantity(int64(m.GPUs()), resource.DecimalSI)
+		dep.Spec.Template.Spec.Tolerations = []corev1.Toleration{{
+			Key:      string(gpuResourceName(m)),
+			Operator: corev1.TolerationOpExists,
+			Effect:   corev1.TaintEffectNoSchedule,
+		}}
+	}
+
+	// Prefer the node already running models of the same GPU sharing group
+	if m.Spec.GPUSharing != nil {
+		dep.Spec.Template.Labels[gpuSharingGroupLabel] = m.Spec.GPUSharing.Group
+		dep.Spec.Template.Spec.Affinity = &corev1.Affinity{
+			PodAffinity: &corev1.PodAffinity{
+				PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{{
+					Weight: 100,
+					PodAffinityTerm: corev1.PodAffinityTerm{
+						LabelSelector: &metav1.LabelSelector{
+							MatchLabels: map[string]string{gpuSharingGroupLabel: m.Spec.GPUSharing.Group},
+						},
+						TopologyKey: "kubernetes.io/hostname",
+					},
+				}},
+			},
+		}
+	}
+
+	// Export the GPU metrics of the pod next to the server
+	if dcgmExporter(m) {
+		podSpec := &dep.Spec.Template.Spec
+		podSpec.Containers = append(podSpec.Containers, dcgmExporterContainer())
+		podSpec.Volumes = append(podSpec.Volumes, podResourcesVolume())
+	}
+
+	// Expose the additional server endpoints
+	dep.Spec.Template.Spec.Containers[0].Ports = append(dep.Spec.Template.Spec.Containers[0].Ports, m.Spec.ExtraPorts...)
+
+	// Hold off liveness checks until the server reports the model loaded
+	if loadingEndpoint(m) != "" {
+		dep.Spec.Template.Spec.Containers[0].StartupProbe = startupProbeForModelServe(m)
+	}
+
+	// Only count the pod ready once the model completes a prompt
+	if m.Spec.CompletionProbe != nil {
+		dep.Spec.Template.Spec.Containers[0].ReadinessProbe = completionProbeForModelServe(m)
+	}
+
+	// Mount the runtime configuration file into the server container
+	if m.Spec.ConfigFile != nil {
+		podSpec := &dep.Spec.Template.Spec
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name: "config-file",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: m.Spec.ConfigFile.ConfigMapName},
+				},
+			},
+		})
+		podSpec.Containers[0].VolumeMounts = append(podSpec.Containers[0].VolumeMounts, corev1.VolumeMount{
+			Name:      "config-file",
+			MountPath: m.Spec.ConfigFile.MountPath,
+			ReadOnly:  true,
+		})
+	}
+
+	// Keep the pods on the nodes of the model and its class
+	if len(m.Spec.NodeSelector) > 0 {
+		nodeSelector := make(map[string]string, len(m.Spec.NodeSelector))
+		for k, v := range m.Spec.NodeSelector {
+			nodeSelector[k] = v
+		}
+		dep.Spec.Template.Spec.NodeSelector = nodeSelector
+	}
+
+	// Publish per pod DNS records under the headless Service
+	if m.Spec.HeadlessService {
+		dep.Spec.Template.Spec.Subdomain = headlessServiceName(m)
+	}
+
+	// Run the pod, including the containers added above, as non-root
+	applySecurityProfile(&dep.Spec.Template.Spec, m)
+
+	// Keep the server root filesystem read-only with writable scratch space
+	applyReadOnlyRoot(&dep.Spec.Template.Spec, m)
+
+	return dep
+}
+
+// modelServing reports whether a model server pod is available. A ready
+// activator does not serve the model.
+func modelServing(m *modelv1alpha1.ModelServe, dep *appsv1.Deployment) bool {
+	return dep.Status.AvailableReplicas > 0 && !awaitingActivation(m)
+}
+
+// modelReadOnly reports whether the server container may not modify the model
+func modelReadOnly(m *modelv1alpha1.ModelServe) bool {
+	return m.Spec.ModelReadOnly == nil || *m.Spec.ModelReadOnly
+}
+
+// gpuResourceName returns the extended resource the GPU count is requested as
+func gpuResourceName(m *modelv1alpha1.ModelServe) corev1.ResourceName {
+	if m.Spec.GPUResourceName != "" {
+		return corev1.ResourceName(m.Spec.GPUResourceName)
+	}
+	return "nvidia.com/gpu"
+}
+
+// serverImage returns the model server image. FORCE_IMAGE lets cluster admins
+// pin every model to a vetted image regardless of spec.image.
+func serverImage(m *modelv1alpha1.ModelServe) string {
+	if forced := os.Getenv("FORCE_IMAGE"); forced != "" {
+		return forced
+	}
+	if m.Spec.Image != "" {
+		return m.Spec.Image
+	}
+	return "ghcr.io/ggerganov/llama.cpp:server"
+}
+
+// serverMemoryLimit returns the memory limit of the model server in MB
+func serverMemoryLimit(m *modelv1alpha1.ModelServe) int32 {
+	if m.Spec.MemoryLimit == 0 {
+		return 4096 // 4GB default
+	}
+	return m.Spec.MemoryLimit
+}
+
+// serverCPULimit returns the CPU limit of the model server in millicores
+func serverCPULimit(m *modelv1alpha1.ModelServe) int32 {
+	if m.Spec.CPULimit == 0 {
+		return 2000 // 2 cores default
+	}
+	return m.Spec.CPULimit
+}
+
+// imageOverrideNote describes a forced image replacing spec.image, if any
+func imageOverrideNote(m *modelv1alpha1.ModelServe) string {
+	forced := os.Getenv("FORCE_IMAGE")
+	if forced == "" || forced == m.Spec.Image {
+		return ""
+	}
+	requested := m.Spec.Image
+	if requested == "" {
+		requested = "the default image"
+	}
+	return fmt.Sprintf("FORCE_IMAGE %s overrides %s", forced, requested)
+}
+
+// minioLocation resolves the MinIO endpoint, bucket and object path of the model
+func minioLocation(m *modelv1alpha1.ModelServe) (endpoint, bucket, objectPath string) {
+	endpoint = m.Spec.MinIOEndpoint
+	if endpoint == "" {
+		endpoint = getEnvOrDefault("MINIO_ENDPOINT", "minio:9000")
+	}
+
+	bucket = m.Spec.MinIOBucket
+	if bucket == "" {
+		bucket = getEnvOrDefault("MINIO_BUCKET", "inference-models")
+	}
+
+	objectPath = m.Spec.MinIOPath
+	if objectPath == "" {
+		objectPath = m.DefaultMinIOPath()
+	}
+	return endpoint, bucket, objectPath
+}
+
+// downloadImage is the image of the containers downloading the model
+func downloadImage(m *modelv1alpha1.ModelServe) string {
+	if m.Spec.Download != nil && m.Spec.Download.Image != "" {
+		return m.Spec.Download.Image
+	}
+	return "minio/mc:latest"
+}
+
+// customDownloadScript renders download.command for a model written to dest.
+// It reports false when the model uses the built-in download, including when
+// the cluster did not enable custom commands. A command that does not render
+// fails the download rather than silently fetching something else.
+func customDownloadScript(m *modelv1alpha1.ModelServe, dest string) (string, bool) {
+	if m.Spec.Download == nil || m.Spec.Download.Command == "" || !modelv1alpha1.CustomDownloadCommandAllowed() {
+		return "", false
+	}
+	endpoint, bucket, objectPath := minioLocation(m)
+	script, err := modelv1alpha1.RenderDownloadCommand(m.Spec.Download.Command, modelv1alpha1.DownloadCommandVars{
+		ModelName: m.Spec.ModelName,
+		Endpoint:  endpoint,
+		Bucket:    bucket,
+		Path:      objectPath,
+		Dest:      dest,
+	})
+	if err != nil {
+		return fmt.Sprintf("echo %q\nexit 1", "download.command: "+err.Error()), true
+	}
+	return script, true
+}
+
+// downloadVerification returns the script verifying the model at dest
+// against download.sha256, or else download.checksumObject, removing it on a
+// mismatch. It is empty when the model declares no checksum.
+func downloadVerification(m *modelv1alpha1.ModelServe, dest string) string {
+	if m.Spec.Download == nil {
+		return ""
+	}
+	if m.Spec.Download.SHA256 != "" {
+		return fmt.Sprintf(`
+echo "Verifying model checksum..."
+if ! echo "%[1]s  %[2]s" | sha256sum -c - > /dev/null; then
+  echo "Downloaded model does not match download.sha256"
+  rm -f %[2]s
+  exit 1
+fi
+`, m.Spec.Download.SHA256, dest)
+	}
+	if m.Spec.Download.ChecksumObject != "" {
+		return checksumObjectVerification(m, dest)
+	}
+	return ""
+}
+
+// checksumObjectVerification returns the script verifying the model at dest
+// against the checksum file download.checksumObject stored next to it in
+// MinIO. The file holds the hex digest, optionally followed by a file name as
+// written by sha256sum.
+func checksumObjectVerification(m *modelv1alpha1.ModelServe, dest string) string {
+	endpoint, bucket, _ := minioLocation(m)
+	object := fmt.Sprintf("minio/%s/%s", bucket, m.Spec.Download.ChecksumObject)
+	return fmt.Sprintf(`
+echo "Fetching model checksum from %[3]s..."
+mc alias set minio http://%[1]s $MINIO_ACCESS_KEY $MINIO_SECRET_KEY > /dev/null
+expected=$(mc cat %[3]s | cut -d ' ' -f 1)
+echo "Verifying model checksum..."
+if [ -z "$expected" ] || ! echo "$expected  %[2]s" | sha256sum -c - > /dev/null; then
+  echo "Downloaded model does not match %[3]s"
+  rm -f %[2]s
+  exit 1
+fi
+`, endpoint, dest, object)
+}
+
+// minioCredentialsEnv returns the MinIO credential environment for download containers
+func minioCredentialsEnv() []corev1.EnvVar {
+	return []corev1.EnvVar{
+		{
+			Name: "MINIO_ACCESS_KEY",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: minioCredentialsSecret},
+					Key:                  "MINIO_ACCESS_KEY",
+				},
+			},
+		},
+		{
+			Name: "MINIO_SECRET_KEY",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: minioCredentialsSecret},
+					Key:                  "MINIO_SECRET_KEY",
+				},
+			},
+		},
+	}
+}
+
+// dropFlag removes the flags and their values from args, in both the
+// "--flag value" and the "--flag=value" form
+func dropFlag(args []string, names ...string) []string {
+	kept := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		name, _, inline := strings.Cut(args[i], "=")
+		matched := false
+		for _, n := range names {
+			if name == n {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			kept = append(kept, args[i])
+			continue
+		}
+		if !inline && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+			i++
+		}
+	}
+	return kept
+}
+
+// downloadEnv returns the environment of download containers: the MinIO
+// credentials and the proxy settings of spec.download. Both spellings of the
+// proxy variables are set, as curl only reads the lower case ones.
+func downloadEnv(m *modelv1alpha1.ModelServe) []corev1.EnvVar {
+	env := minioCredentialsEnv()
+	if m.Spec.Download == nil {
+		return env
+	}
+
+	for _, proxy := range []struct{ name, value string }{
+		{"HTTP_PROXY", m.Spec.Download.HTTPProxy},
+		{"HTTPS_PROXY", m.Spec.Download.HTTPSProxy},
+		{"NO_PROXY", m.Spec.Download.NoProxy},
+	} {
+		if proxy.value == "" {
+			continue
+		}
+		env = append(env,
+			corev1.EnvVar{Name: proxy.name, Value: proxy.value},
+			corev1.EnvVar{Name: strings.ToLower(proxy.name), Value: proxy.value},
+		)
+	}
+	return env
+}
+
+// podLabelsForModelServe merges the user pod labels with the managed labels.
+// Managed labels always win so the Service and Deployment selectors keep matching.
+func podLabelsForModelServe(m *modelv1alpha1.ModelServe) map[string]string {
+	podLabels := make(map[string]string, len(m.Spec.PodLabels)+2)
+	for k, v := range m.Spec.PodLabels {
+		podLabels[k] = v
+	}
+	for k, v := range labelsForModelServe(m.Name) {
+		podLabels[k] = v
+	}
+	return podLabels
+}
+
+// deploymentCondition returns the condition of the given type, if present
+func deploymentCondition(dep *appsv1.Deployment, condType appsv1.DeploymentConditionType) *appsv1.DeploymentCondition {
+	for i := range dep.Status.Conditions {
+		if dep.Status.Conditions[i].Type == condType {
+			return &dep.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// checkPodSelector records the user pod labels dropped because they would
+// have broken the Service selector, and warns once when they change
+func (r *ModelServeReconciler) checkPodSelector(ctx context.Context, m *modelv1alpha1.ModelServe) error {
+	ls := labelsForModelServe(m.Name)
+	var ignored []string
+	for k := range ls {
+		if v, ok := m.Spec.PodLabels[k]; ok && v != ls[k] {
+			ignored = append(ignored, k+"="+v)
+		}
+	}
+	sort.Strings(ignored)
+
+	if equality.Semantic.DeepEqual(m.Status.IgnoredPodLabels, ignored) {
+		return nil
+	}
+	if len(ignored) > 0 {
+		r.Recorder.Eventf(m, corev1.EventTypeWarning, "SelectorConflict",
+			"podLabels %s conflict with the Service selector and were ignored", strings.Join(ignored, ", "))
+	}
+	m.Status.IgnoredPodLabels = ignored
+	return r.updateStatus(ctx, m)
 }
 
 // serviceForModelServe returns a modelServe Service object
 func (r *ModelServeReconciler) serviceForModelServe(m *modelv1alpha1.ModelServe) *corev1.Service {
 	ls := labelsForModelServe(m.Name)
-	return &corev1.Service{
+	svc := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      m.Name,
-			Namespace: m.Namespace,
-			Labels:    ls,
+			Name:        m.Name,
+			Namespace:   m.Namespace,
+			Labels:      ls,
+			Annotations: withManagedAnnotations(propagatedAnnotations(m)),
 		},
 		Spec: corev1.ServiceSpec{
 			Selector: ls,
 			Ports: []corev1.ServicePort{{
+				Name:       "http",
 				Port:       80,
 				TargetPort: intstr.FromInt(8080),
 			}},
 			Type: corev1.ServiceTypeClusterIP,
 		},
 	}
+
+	if exposeMetrics(m) {
+		svc.Spec.Ports = append(svc.Spec.Ports, corev1.ServicePort{
+			Name:       "metrics",
+			Port:       metricsPort,
+			TargetPort: intstr.FromString("metrics"),
+		})
+	}
+
+	if dcgmExporter(m) {
+		svc.Spec.Ports = append(svc.Spec.Ports, corev1.ServicePort{
+			Name:       "dcgm",
+			Port:       dcgmPort,
+			TargetPort: intstr.FromString("dcgm"),
+		})
+	}
+
+	// Mirror the extra server ports under the same number
+	for _, p := range m.Spec.ExtraPorts {
+		svc.Spec.Ports = append(svc.Spec.Ports, corev1.ServicePort{
+			Name:       p.Name,
+			Port:       p.ContainerPort,
+			TargetPort: intstr.FromInt(int(p.ContainerPort)),
+			Protocol:   p.Protocol,
+		})
+	}
+
+	return svc
+}
+
+// servicePortsMatch compares the ports the operator sets, ignoring the
+// fields defaulted by the API server
+func servicePortsMatch(found, desired []corev1.ServicePort) bool {
+	if len(found) != len(desired) {
+		return false
+	}
+	for i := range desired {
+		if found[i].Name != desired[i].Name || found[i].Port != desired[i].Port || found[i].TargetPort != desired[i].TargetPort {
+			return false
+		}
+	}
+	return true
+}
+
+// exposeMetrics reports whether the sidecar metrics are routed through the gateway
+func exposeMetrics(m *modelv1alpha1.ModelServe) bool {
+	return m.Spec.Monitoring != nil && m.Spec.Monitoring.ExposeThroughGateway
+}
+
+// middlewaresAnnotation holds the Traefik middleware chain of an Ingress
+const middlewaresAnnotation = "traefik.ingress.kubernetes.io/router.middlewares"
+
+// middlewareChain returns the Traefik middlewares of the model route in the
+// order they apply: JWT auth first, unless the model is public, then strip
+// prefix.
+// Format: namespace-middlewarename@kubernetescrd
+func middlewareChain(m *modelv1alpha1.ModelServe) []string {
+	var chain []string
+	if !m.Spec.Public {
+		chain = append(chain, fmt.Sprintf("%s-jwt-auth@kubernetescrd", m.Namespace))
+	}
+	return append(chain, fmt.Sprintf("%s-%s-stripprefix@kubernetescrd", m.Namespace, m.Name))
+}
+
+// appliedMiddlewares returns the middleware chain set on the Ingress
+func appliedMiddlewares(ing *networkingv1.Ingress) []string {
+	chain := ing.Annotations[middlewaresAnnotation]
+	if chain == "" {
+		return nil
+	}
+	return strings.Split(chain, ",")
 }
 
 // ingressForModelServe returns a modelServe Ingress object with JWT auth middleware
@@ -489,20 +1484,47 @@ func (r *ModelServeReconciler) ingressForModelServe(m *modelv1alpha1.ModelServe)
 	ls := labelsForModelServe(m.Name)
 	pathType := networkingv1.PathTypePrefix
 
-	// Chain JWT auth middleware with strip prefix middleware
-	// Format: namespace-middlewarename@kubernetescrd
-	middlewares := fmt.Sprintf("%s-jwt-auth@kubernetescrd,%s-%s-stripprefix@kubernetescrd",
-		m.Namespace, m.Namespace, m.Name)
+	paths := []networkingv1.HTTPIngressPath{
+		{
+			Path:     m.RoutePath(),
+			PathType: &pathType,
+			Backend: networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: m.Name,
+					Port: networkingv1.ServiceBackendPort{
+						Number: 80,
+					},
+				},
+			},
+		},
+	}
+
+	// The more specific metrics path takes precedence over the model path.
+	// After strip prefix the sidecar receives /metrics.
+	if exposeMetrics(m) {
+		paths = append(paths, networkingv1.HTTPIngressPath{
+			Path:     m.RoutePath() + "/metrics",
+			PathType: &pathType,
+			Backend: networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: m.Name,
+					Port: networkingv1.ServiceBackendPort{
+						Number: metricsPort,
+					},
+				},
+			},
+		})
+	}
+
+	annotations := propagatedAnnotations(m)
+	annotations[middlewaresAnnotation] = strings.Join(middlewareChain(m), ",")
 
 	return &networkingv1.Ingress{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      m.Name,
-			Namespace: m.Namespace,
-			Annotations: map[string]string{
-				// Traefik middleware chain: JWT auth first, then strip prefix
-				"traefik.ingress.kubernetes.io/router.middlewares": middlewares,
-			},
-			Labels: ls,
+			Name:        m.Name,
+			Namespace:   m.Namespace,
+			Annotations: withManagedAnnotations(annotations),
+			Labels:      ls,
 		},
 		Spec: networkingv1.IngressSpec{
 			IngressClassName: func() *string { s := "traefik"; return &s }(),
@@ -510,20 +1532,7 @@ func (r *ModelServeReconciler) ingressForModelServe(m *modelv1alpha1.ModelServe)
 				{
 					IngressRuleValue: networkingv1.IngressRuleValue{
 						HTTP: &networkingv1.HTTPIngressRuleValue{
-							Paths: []networkingv1.HTTPIngressPath{
-								{
-									Path:     "/" + m.Name,
-									PathType: &pathType,
-									Backend: networkingv1.IngressBackend{
-										Service: &networkingv1.IngressServiceBackend{
-											Name: m.Name,
-											Port: networkingv1.ServiceBackendPort{
-												Number: 80,
-											},
-										},
-									},
-								},
-							},
+							Paths: paths,
 						},
 					},
 				},
@@ -532,6 +1541,252 @@ func (r *ModelServeReconciler) ingressForModelServe(m *modelv1alpha1.ModelServe)
 	}
 }
 
+// reconcileNetworkPolicy creates, updates or removes the model's NetworkPolicy
+func (r *ModelServeReconciler) reconcileNetworkPolicy(ctx context.Context, m *modelv1alpha1.ModelServe) error {
+	found := &networkingv1.NetworkPolicy{}
+	err := r.Get(ctx, types.NamespacedName{Name: m.Name, Namespace: m.Namespace}, found)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	exists := err == nil
+
+	if m.Spec.NetworkPolicy == nil || !m.Spec.NetworkPolicy.Enabled {
+		if exists && metav1.IsControlledBy(found, m) {
+			return r.Delete(ctx, found)
+		}
+		return nil
+	}
+
+	np := r.networkPolicyForModelServe(m)
+	if err := ctrl.SetControllerReference(m, np, r.Scheme); err != nil {
+		return err
+	}
+
+	if !exists {
+		return r.Create(ctx, np)
+	}
+	if !equality.Semantic.DeepEqual(found.Spec, np.Spec) {
+		found.Spec = np.Spec
+		return r.Update(ctx, found)
+	}
+	return nil
+}
+
+// networkPolicyForModelServe returns a NetworkPolicy allowing ingress only from
+// the gateway and the operator, which polls the pods directly, and egress only
+// to MinIO, the monitoring database and DNS
+func (r *ModelServeReconciler) networkPolicyForModelServe(m *modelv1alpha1.ModelServe) *networkingv1.NetworkPolicy {
+	ls := labelsForModelServe(m.Name)
+	tcp := corev1.ProtocolTCP
+	udp := corev1.ProtocolUDP
+	port := func(p int) *intstr.IntOrString { v := intstr.FromInt(p); return &v }
+
+	ingressFrom := m.Spec.NetworkPolicy.IngressFrom
+	if len(ingressFrom) == 0 {
+		traefikNamespace := getEnvOrDefault("TRAEFIK_NAMESPACE", "kube-system")
+		operatorNamespace := getEnvOrDefault("OPERATOR_NAMESPACE", "default")
+		ingressFrom = []networkingv1.NetworkPolicyPeer{{
+			NamespaceSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"kubernetes.io/metadata.name": traefikNamespace},
+			},
+		}}
+		if operatorNamespace != traefikNamespace {
+			ingressFrom = append(ingressFrom, networkingv1.NetworkPolicyPeer{
+				NamespaceSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"kubernetes.io/metadata.name": operatorNamespace},
+				},
+			})
+		}
+	}
+
+	// DNS is always allowed so MinIO and the database can be resolved
+	egress := []networkingv1.NetworkPolicyEgressRule{{
+		Ports: []networkingv1.NetworkPolicyPort{
+			{Protocol: &udp, Port: port(53)},
+			{Protocol: &tcp, Port: port(53)},
+		},
+	}}
+	if len(m.Spec.NetworkPolicy.EgressTo) > 0 {
+		egress = append(egress, networkingv1.NetworkPolicyEgressRule{To: m.Spec.NetworkPolicy.EgressTo})
+	} else {
+		egress = append(egress,
+			networkingv1.NetworkPolicyEgressRule{
+				To: []networkingv1.NetworkPolicyPeer{{
+					PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "minio"}},
+				}},
+				Ports: []networkingv1.NetworkPolicyPort{{Protocol: &tcp, Port: port(9000)}},
+			},
+			networkingv1.NetworkPolicyEgressRule{
+				To: []networkingv1.NetworkPolicyPeer{{
+					PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "postgres"}},
+				}},
+				Ports: []networkingv1.NetworkPolicyPort{{Protocol: &tcp, Port: port(5432)}},
+			},
+		)
+	}
+
+	// The activator annotates its ModelServe through the API server, whose
+	// address is not known here
+	if awaitingActivation(m) {
+		egress = append(egress, networkingv1.NetworkPolicyEgressRule{
+			Ports: []networkingv1.NetworkPolicyPort{
+				{Protocol: &tcp, Port: port(443)},
+				{Protocol: &tcp, Port: port(6443)},
+			},
+		})
+	}
+
+	ingressPorts := []networkingv1.NetworkPolicyPort{{Protocol: &tcp, Port: port(8080)}}
+	if exposeMetrics(m) {
+		ingressPorts = append(ingressPorts, networkingv1.NetworkPolicyPort{Protocol: &tcp, Port: port(metricsPort)})
+	}
+	if dcgmExporter(m) {
+		ingressPorts = append(ingressPorts, networkingv1.NetworkPolicyPort{Protocol: &tcp, Port: port(dcgmPort)})
+	}
+	for _, p := range m.Spec.ExtraPorts {
+		protocol := corev1.ProtocolTCP
+		if p.Protocol != "" {
+			protocol = p.Protocol
+		}
+		ingressPorts = append(ingressPorts, networkingv1.NetworkPolicyPort{Protocol: &protocol, Port: port(int(p.ContainerPort))})
+	}
+
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      m.Name,
+			Namespace: m.Namespace,
+			Labels:    ls,
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			// Every pod of the model, including a retained failed pod without
+			// the app label and the download Jobs
+			PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"model_serve_cr": m.Name}},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+			Ingress: []networkingv1.NetworkPolicyIngressRule{{
+				From:  ingressFrom,
+				Ports: ingressPorts,
+			}},
+			Egress: egress,
+		},
+	}
+}
+
+// propagatedAnnotations returns the ModelServe annotations copied onto its
+// Deployment, Service and Ingress, such as Argo CD sync waves. The prefixes are
+// configured as a comma separated PROPAGATE_ANNOTATION_PREFIXES.
+func propagatedAnnotations(m *modelv1alpha1.ModelServe) map[string]string {
+	prefixes := strings.Split(getEnvOrDefault("PROPAGATE_ANNOTATION_PREFIXES", "argocd.argoproj.io/"), ",")
+
+	annotations := map[string]string{}
+	for k, v := range m.Annotations {
+		for _, prefix := range prefixes {
+			if prefix = strings.TrimSpace(prefix); prefix != "" && strings.HasPrefix(k, prefix) {
+				annotations[k] = v
+				break
+			}
+		}
+	}
+	return annotations
+}
+
+// managedAnnotationPrefixes are annotation prefixes owned by the operator and
+// the Deployment controller
+var managedAnnotationPrefixes = []string{"model.example.com/", "deployment.kubernetes.io/"}
+
+// deploymentAnnotationsForModelServe merges spec.deploymentAnnotations with the
+// propagated annotations. Managed keys are dropped from the user annotations.
+func deploymentAnnotationsForModelServe(m *modelv1alpha1.ModelServe) map[string]string {
+	annotations := map[string]string{}
+	for k, v := range m.Spec.DeploymentAnnotations {
+		managed := false
+		for _, prefix := range managedAnnotationPrefixes {
+			if strings.HasPrefix(k, prefix) {
+				managed = true
+				break
+			}
+		}
+		if !managed {
+			annotations[k] = v
+		}
+	}
+	for k, v := range propagatedAnnotations(m) {
+		annotations[k] = v
+	}
+	return annotations
+}
+
+// setTemplateHash records the hash of the pod template on the Deployment
+func setTemplateHash(dep *appsv1.Deployment) {
+	// Marshalling a pod template cannot fail
+	raw, _ := json.Marshal(dep.Spec.Template)
+	sum := sha256.Sum256(raw)
+	if dep.Annotations == nil {
+		dep.Annotations = map[string]string{}
+	}
+	dep.Annotations[templateHashAnnotation] = hex.EncodeToString(sum[:])[:16]
+}
+
+// mergeAnnotations adds the annotations to obj and reports whether it changed
+func mergeAnnotations(obj metav1.Object, annotations map[string]string) bool {
+	current := obj.GetAnnotations()
+	changed := false
+	for k, v := range annotations {
+		if cur, ok := current[k]; !ok || cur != v {
+			if current == nil {
+				current = map[string]string{}
+			}
+			current[k] = v
+			changed = true
+		}
+	}
+	obj.SetAnnotations(current)
+	return changed
+}
+
+// managedAnnotationsAnnotation lists the annotations the operator copied onto
+// an object, so those no longer wanted are removed again
+const managedAnnotationsAnnotation = "model.example.com/managed-annotations"
+
+// withManagedAnnotations records the keys of annotations as managed and
+// returns annotations
+func withManagedAnnotations(annotations map[string]string) map[string]string {
+	delete(annotations, managedAnnotationsAnnotation)
+	keys := make([]string, 0, len(annotations))
+	for k := range annotations {
+		keys = append(keys, k)
+	}
+	if len(keys) > 0 {
+		sort.Strings(keys)
+		annotations[managedAnnotationsAnnotation] = strings.Join(keys, ",")
+	}
+	return annotations
+}
+
+// syncAnnotations adds the annotations to obj and removes the ones it managed
+// before that are no longer among them, e.g. an Argo CD sync wave dropped from
+// the ModelServe. Annotations set by others are kept. It reports whether obj
+// changed.
+func syncAnnotations(obj metav1.Object, annotations map[string]string) bool {
+	desired := map[string]string{}
+	for k, v := range annotations {
+		desired[k] = v
+	}
+	withManagedAnnotations(desired)
+
+	current := obj.GetAnnotations()
+	changed := false
+	for _, k := range append(strings.Split(current[managedAnnotationsAnnotation], ","), managedAnnotationsAnnotation) {
+		if _, ok := desired[k]; ok {
+			continue
+		}
+		if _, ok := current[k]; ok {
+			delete(current, k)
+			changed = true
+		}
+	}
+	return mergeAnnotations(obj, desired) || changed
+}
+
 // labelsForModelServe returns the labels for selecting the resources
 // belonging to the given modelServe CR name.
 func labelsForModelServe(name string) map[string]string {
@@ -545,5 +1800,10 @@ func (r *ModelServeReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Owns(&appsv1.Deployment{}).
 		Owns(&corev1.Service{}).
 		Owns(&networkingv1.Ingress{}).
+		Owns(&networkingv1.NetworkPolicy{}).
+		Owns(&batchv1.Job{}).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.modelServesForConfigMap)).
+		Watches(&corev1.Node{}, handler.EnqueueRequestsFromMapFunc(r.modelServesForNode)).
+		Watches(&modelv1alpha1.ModelServeClass{}, handler.EnqueueRequestsFromMapFunc(r.modelServesForClass)).
 		Complete(r)
 }
@@ -0,0 +1,1226 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	modelv1alpha1 "github.com/example/model-operator/api/v1alpha1"
+)
+
+// newTestReconciler returns a reconciler backed by a fake client seeded with objs
+func newTestReconciler(t *testing.T, objs ...client.Object) *ModelServeReconciler {
+	t.Helper()
+
+	s := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	if err := modelv1alpha1.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+
+	// The MinIO credentials every model downloads with
+	credentials := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: minioCredentialsSecret, Namespace: "default"},
+		Data: map[string][]byte{
+			"MINIO_ACCESS_KEY": []byte("minioadmin"),
+			"MINIO_SECRET_KEY": []byte("minioadmin123"),
+		},
+	}
+
+	c := fake.NewClientBuilder().
+		WithScheme(s).
+		WithObjects(append(objs, credentials)...).
+		WithStatusSubresource(&modelv1alpha1.ModelServe{}).
+		Build()
+	return &ModelServeReconciler{Client: c, Scheme: s, Recorder: record.NewFakeRecorder(100)}
+}
+
+// newTestModelServe returns a minimal ModelServe in the default namespace
+func newTestModelServe(name string) *modelv1alpha1.ModelServe {
+	return &modelv1alpha1.ModelServe{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: modelv1alpha1.ModelServeSpec{
+			ModelName: name + ".gguf",
+			ModelUUID: name + "-uuid",
+			MinIOPath: "models/" + name + ".gguf",
+		},
+	}
+}
+
+// reconcileUntilStable reconciles the named ModelServe until no requeue is requested
+func reconcileUntilStable(t *testing.T, r *ModelServeReconciler, name string) {
+	t.Helper()
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: "default"}}
+	for i := 0; i < 10; i++ {
+		res, err := r.Reconcile(context.Background(), req)
+		if err != nil {
+			t.Fatalf("reconcile %s: %v", name, err)
+		}
+		if !res.Requeue {
+			return
+		}
+	}
+	t.Fatalf("reconcile %s did not settle", name)
+}
+
+// drainEvents returns the events recorded so far by the fake recorder
+func drainEvents(r *ModelServeReconciler) []string {
+	var events []string
+	recorder := r.Recorder.(*record.FakeRecorder)
+	for {
+		select {
+		case e := <-recorder.Events:
+			events = append(events, e)
+		default:
+			return events
+		}
+	}
+}
+
+// getDeployment fetches the Deployment generated for the named ModelServe
+func getDeployment(t *testing.T, r *ModelServeReconciler, name string) *appsv1.Deployment {
+	t.Helper()
+
+	dep := &appsv1.Deployment{}
+	if err := r.Get(context.Background(), types.NamespacedName{Name: name, Namespace: "default"}, dep); err != nil {
+		t.Fatalf("get deployment %s: %v", name, err)
+	}
+	return dep
+}
+
+// getModelServe fetches the named ModelServe
+func getModelServe(t *testing.T, r *ModelServeReconciler, name string) *modelv1alpha1.ModelServe {
+	t.Helper()
+
+	ms := &modelv1alpha1.ModelServe{}
+	if err := r.Get(context.Background(), types.NamespacedName{Name: name, Namespace: "default"}, ms); err != nil {
+		t.Fatalf("get modelserve %s: %v", name, err)
+	}
+	return ms
+}
+
+func TestConfigFileMountAndReload(t *testing.T) {
+	ms := newTestModelServe("cfg")
+	ms.Spec.ConfigFile = &modelv1alpha1.ConfigFileSpec{ConfigMapName: "cfg-runtime", MountPath: "/config"}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "cfg-runtime", Namespace: "default"},
+		Data:       map[string]string{"server.json": `{"threads": 4}`},
+	}
+	r := newTestReconciler(t, ms, cm)
+
+	reconcileUntilStable(t, r, "cfg")
+	dep := getDeployment(t, r, "cfg")
+
+	var mounted bool
+	for _, vm := range dep.Spec.Template.Spec.Containers[0].VolumeMounts {
+		if vm.Name == "config-file" && vm.MountPath == "/config" && vm.ReadOnly {
+			mounted = true
+		}
+	}
+	if !mounted {
+		t.Fatalf("config file not mounted at /config: %+v", dep.Spec.Template.Spec.Containers[0].VolumeMounts)
+	}
+
+	oldHash := dep.Spec.Template.Annotations[configHashAnnotation]
+	if oldHash == "" {
+		t.Fatal("expected config hash annotation on the pod template")
+	}
+
+	// Editing the ConfigMap must roll the pods
+	cm.Data["server.json"] = `{"threads": 8}`
+	if err := r.Update(context.Background(), cm); err != nil {
+		t.Fatal(err)
+	}
+	reqs := r.modelServesForConfigMap(context.Background(), cm)
+	if len(reqs) != 1 || reqs[0].Name != "cfg" {
+		t.Fatalf("expected ConfigMap event to enqueue cfg, got %v", reqs)
+	}
+
+	reconcileUntilStable(t, r, "cfg")
+	dep = getDeployment(t, r, "cfg")
+	if newHash := dep.Spec.Template.Annotations[configHashAnnotation]; newHash == oldHash {
+		t.Fatalf("expected config hash to change after ConfigMap edit, still %s", newHash)
+	}
+}
+
+func TestNetworkPolicyDefaultPeersIncludeOperator(t *testing.T) {
+	t.Setenv("TRAEFIK_NAMESPACE", "traefik")
+	t.Setenv("OPERATOR_NAMESPACE", "model-operator")
+
+	ms := newTestModelServe("np-default")
+	ms.Spec.NetworkPolicy = &modelv1alpha1.NetworkPolicySpec{Enabled: true}
+	r := newTestReconciler(t, ms)
+	reconcileUntilStable(t, r, "np-default")
+
+	np := &networkingv1.NetworkPolicy{}
+	if err := r.Get(context.Background(), types.NamespacedName{Name: "np-default", Namespace: "default"}, np); err != nil {
+		t.Fatalf("get networkpolicy: %v", err)
+	}
+	var namespaces []string
+	for _, peer := range np.Spec.Ingress[0].From {
+		namespaces = append(namespaces, peer.NamespaceSelector.MatchLabels["kubernetes.io/metadata.name"])
+	}
+	if len(namespaces) != 2 || namespaces[0] != "traefik" || namespaces[1] != "model-operator" {
+		t.Fatalf("expected the gateway and operator namespaces as peers, got %v", namespaces)
+	}
+}
+
+func TestNetworkPolicyUsesConfiguredPeers(t *testing.T) {
+	gateway := networkingv1.NetworkPolicyPeer{
+		NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"kubernetes.io/metadata.name": "traefik"}},
+	}
+	storage := networkingv1.NetworkPolicyPeer{
+		PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "object-store"}},
+	}
+
+	ms := newTestModelServe("np")
+	ms.Spec.NetworkPolicy = &modelv1alpha1.NetworkPolicySpec{
+		Enabled:     true,
+		IngressFrom: []networkingv1.NetworkPolicyPeer{gateway},
+		EgressTo:    []networkingv1.NetworkPolicyPeer{storage},
+	}
+	r := newTestReconciler(t, ms)
+	reconcileUntilStable(t, r, "np")
+
+	np := &networkingv1.NetworkPolicy{}
+	if err := r.Get(context.Background(), types.NamespacedName{Name: "np", Namespace: "default"}, np); err != nil {
+		t.Fatalf("get networkpolicy: %v", err)
+	}
+
+	if owner := metav1.GetControllerOf(np); owner == nil || owner.Kind != "ModelServe" || owner.Name != "np" {
+		t.Fatalf("expected NetworkPolicy to be owned by the ModelServe, got %+v", np.OwnerReferences)
+	}
+	if len(np.Spec.Ingress) != 1 || !equality.Semantic.DeepEqual(np.Spec.Ingress[0].From, []networkingv1.NetworkPolicyPeer{gateway}) {
+		t.Fatalf("unexpected ingress rules: %+v", np.Spec.Ingress)
+	}
+
+	// The first egress rule is DNS, the configured peers follow
+	if len(np.Spec.Egress) != 2 || !equality.Semantic.DeepEqual(np.Spec.Egress[1].To, []networkingv1.NetworkPolicyPeer{storage}) {
+		t.Fatalf("unexpected egress rules: %+v", np.Spec.Egress)
+	}
+}
+
+func TestConflictingPodLabelWarns(t *testing.T) {
+	ms := newTestModelServe("labels")
+	ms.Spec.PodLabels = map[string]string{"app": "custom", "team": "ml"}
+	r := newTestReconciler(t, ms)
+	reconcileUntilStable(t, r, "labels")
+
+	var warned bool
+	for _, e := range drainEvents(r) {
+		if strings.HasPrefix(e, "Warning SelectorConflict") && strings.Contains(e, "app=custom") {
+			warned = true
+		}
+	}
+	if !warned {
+		t.Fatal("expected a SelectorConflict warning event for podLabels app=custom")
+	}
+
+	podLabels := getDeployment(t, r, "labels").Spec.Template.Labels
+	if podLabels["app"] != "model-serve" || podLabels["team"] != "ml" {
+		t.Fatalf("expected managed labels to win and user labels to merge, got %v", podLabels)
+	}
+	if ignored := getModelServe(t, r, "labels").Status.IgnoredPodLabels; len(ignored) != 1 || ignored[0] != "app=custom" {
+		t.Fatalf("expected the ignored label in status, got %v", ignored)
+	}
+
+	// Later reconciles do not warn again
+	reconcileUntilStable(t, r, "labels")
+	for _, e := range drainEvents(r) {
+		if strings.HasPrefix(e, "Warning SelectorConflict") {
+			t.Fatalf("expected a single warning for unchanged podLabels, got %q", e)
+		}
+	}
+}
+
+func TestStartupScriptRunsAsInitStep(t *testing.T) {
+	ms := newTestModelServe("startup")
+	ms.Spec.StartupScript = "mkdir -p /models/cache"
+	r := newTestReconciler(t, ms)
+	reconcileUntilStable(t, r, "startup")
+
+	initContainers := getDeployment(t, r, "startup").Spec.Template.Spec.InitContainers
+	if len(initContainers) != 2 || initContainers[0].Name != "download-model" || initContainers[1].Name != "startup-script" {
+		t.Fatalf("expected startup-script to run after download-model, got %+v", initContainers)
+	}
+
+	script := initContainers[1]
+	if len(script.Args) != 1 || script.Args[0] != "mkdir -p /models/cache" {
+		t.Fatalf("unexpected startup script args: %v", script.Args)
+	}
+	if len(script.VolumeMounts) != 1 || script.VolumeMounts[0].Name != "model-volume" || script.VolumeMounts[0].MountPath != "/models" {
+		t.Fatalf("expected startup script to share the model volume, got %+v", script.VolumeMounts)
+	}
+}
+
+func TestMetricsExposedThroughGateway(t *testing.T) {
+	ms := newTestModelServe("metrics")
+	ms.Spec.Monitoring = &modelv1alpha1.MonitoringSpec{ExposeThroughGateway: true}
+	r := newTestReconciler(t, ms)
+	reconcileUntilStable(t, r, "metrics")
+
+	ing := &networkingv1.Ingress{}
+	if err := r.Get(context.Background(), types.NamespacedName{Name: "metrics", Namespace: "default"}, ing); err != nil {
+		t.Fatalf("get ingress: %v", err)
+	}
+
+	paths := ing.Spec.Rules[0].HTTP.Paths
+	if len(paths) != 2 || paths[1].Path != "/metrics/metrics" || paths[1].Backend.Service.Port.Number != metricsPort {
+		t.Fatalf("expected a /metrics/metrics route to the sidecar metrics port, got %+v", paths)
+	}
+	if mw := ing.Annotations["traefik.ingress.kubernetes.io/router.middlewares"]; !strings.HasPrefix(mw, "default-jwt-auth@kubernetescrd,") {
+		t.Fatalf("expected the metrics route to be protected by the auth middleware, got %q", mw)
+	}
+
+	svc := &corev1.Service{}
+	if err := r.Get(context.Background(), types.NamespacedName{Name: "metrics", Namespace: "default"}, svc); err != nil {
+		t.Fatalf("get service: %v", err)
+	}
+	if len(svc.Spec.Ports) != 2 || svc.Spec.Ports[1].Name != "metrics" || svc.Spec.Ports[1].Port != metricsPort {
+		t.Fatalf("expected a metrics Service port, got %+v", svc.Spec.Ports)
+	}
+}
+
+func TestProgressDeadlineSurfacesInStatus(t *testing.T) {
+	ms := newTestModelServe("deadline")
+	r := newTestReconciler(t, ms)
+	reconcileUntilStable(t, r, "deadline")
+
+	dep := getDeployment(t, r, "deadline")
+	if dep.Spec.ProgressDeadlineSeconds == nil || *dep.Spec.ProgressDeadlineSeconds != 600 {
+		t.Fatalf("expected the default progress deadline of 600s, got %v", dep.Spec.ProgressDeadlineSeconds)
+	}
+
+	// The deployment controller reports the timed out rollout
+	dep.Status.Conditions = []appsv1.DeploymentCondition{{
+		Type:    appsv1.DeploymentProgressing,
+		Status:  corev1.ConditionFalse,
+		Reason:  "ProgressDeadlineExceeded",
+		Message: `ReplicaSet "deadline-5d8f" has timed out progressing.`,
+	}}
+	if err := r.Update(context.Background(), dep); err != nil {
+		t.Fatal(err)
+	}
+	reconcileUntilStable(t, r, "deadline")
+
+	if err := r.Get(context.Background(), types.NamespacedName{Name: "deadline", Namespace: "default"}, ms); err != nil {
+		t.Fatal(err)
+	}
+	if ms.Status.Phase != "Failed" || !strings.Contains(ms.Status.Message, "has timed out progressing") {
+		t.Fatalf("expected the timed out rollout to fail the ModelServe, got %q (%q)", ms.Status.Phase, ms.Status.Message)
+	}
+}
+
+func TestServiceAccountTokenNotMountedByDefault(t *testing.T) {
+	ms := newTestModelServe("token")
+	r := newTestReconciler(t, ms)
+	reconcileUntilStable(t, r, "token")
+
+	automount := getDeployment(t, r, "token").Spec.Template.Spec.AutomountServiceAccountToken
+	if automount == nil || *automount {
+		t.Fatalf("expected the service account token mount to be disabled, got %v", automount)
+	}
+}
+
+func TestWarmupBackendUntilModelAvailable(t *testing.T) {
+	ms := newTestModelServe("warm")
+	ms.Spec.WarmupBackend = true
+	r := newTestReconciler(t, ms)
+	reconcileUntilStable(t, r, "warm")
+
+	backend := func() string {
+		ing := &networkingv1.Ingress{}
+		if err := r.Get(context.Background(), types.NamespacedName{Name: "warm", Namespace: "default"}, ing); err != nil {
+			t.Fatalf("get ingress: %v", err)
+		}
+		return ing.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name
+	}
+
+	// No pod is available yet, so the route answers with 503 from the warmup backend
+	if got := backend(); got != warmupBackendName {
+		t.Fatalf("expected the route to use the warmup backend while not running, got %q", got)
+	}
+	for _, obj := range []client.Object{&corev1.ConfigMap{}, &appsv1.Deployment{}, &corev1.Service{}} {
+		if err := r.Get(context.Background(), types.NamespacedName{Name: warmupBackendName, Namespace: "default"}, obj); err != nil {
+			t.Fatalf("expected warmup backend %T: %v", obj, err)
+		}
+	}
+	warmup := &appsv1.Deployment{}
+	if err := r.Get(context.Background(), types.NamespacedName{Name: warmupBackendName, Namespace: "default"}, warmup); err != nil {
+		t.Fatal(err)
+	}
+	if sc := warmup.Spec.Template.Spec.SecurityContext; sc == nil || sc.RunAsNonRoot == nil || !*sc.RunAsNonRoot {
+		t.Fatalf("expected the warmup backend to run as non-root, got %+v", sc)
+	}
+
+	dep := getDeployment(t, r, "warm")
+	dep.Status.AvailableReplicas = 1
+	if err := r.Update(context.Background(), dep); err != nil {
+		t.Fatal(err)
+	}
+	reconcileUntilStable(t, r, "warm")
+
+	if got := backend(); got != "warm" {
+		t.Fatalf("expected the route to switch to the model Service once available, got %q", got)
+	}
+}
+
+func TestWarmupBackendUpdatesExistingObjects(t *testing.T) {
+	ms := newTestModelServe("warm")
+	ms.Spec.WarmupBackend = true
+	ls := map[string]string{"app": warmupBackendName}
+	meta := metav1.ObjectMeta{Name: warmupBackendName, Namespace: "default", Labels: ls}
+	// The backend an older operator created, running nginx as root on port 80
+	oldConfig := &corev1.ConfigMap{ObjectMeta: meta, Data: map[string]string{"default.conf": "server { listen 80; }"}}
+	oldDep := &appsv1.Deployment{
+		ObjectMeta: meta,
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: ls},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: ls},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{
+					Name:  "nginx",
+					Image: "nginx:alpine",
+					Ports: []corev1.ContainerPort{{ContainerPort: 80, Name: "http"}},
+				}}},
+			},
+		},
+	}
+	oldSvc := &corev1.Service{
+		ObjectMeta: meta,
+		Spec: corev1.ServiceSpec{
+			Selector: ls,
+			Ports:    []corev1.ServicePort{{Name: "http", Port: 80, TargetPort: intstr.FromInt(80)}},
+		},
+	}
+	r := newTestReconciler(t, ms, oldConfig, oldDep, oldSvc)
+	reconcileUntilStable(t, r, "warm")
+
+	key := types.NamespacedName{Name: warmupBackendName, Namespace: "default"}
+	config := &corev1.ConfigMap{}
+	if err := r.Get(context.Background(), key, config); err != nil {
+		t.Fatal(err)
+	}
+	if config.Data["default.conf"] != warmupNginxConfig {
+		t.Fatalf("expected the warmup config to be updated, got %q", config.Data["default.conf"])
+	}
+	warmup := &appsv1.Deployment{}
+	if err := r.Get(context.Background(), key, warmup); err != nil {
+		t.Fatal(err)
+	}
+	container := warmup.Spec.Template.Spec.Containers[0]
+	if container.Image != "nginxinc/nginx-unprivileged:alpine" || container.Ports[0].ContainerPort != 8080 {
+		t.Fatalf("expected the unprivileged image on port 8080, got %s on %d", container.Image, container.Ports[0].ContainerPort)
+	}
+	if sc := warmup.Spec.Template.Spec.SecurityContext; sc == nil || sc.RunAsNonRoot == nil || !*sc.RunAsNonRoot {
+		t.Fatalf("expected the updated warmup backend to run as non-root, got %+v", sc)
+	}
+	svc := &corev1.Service{}
+	if err := r.Get(context.Background(), key, svc); err != nil {
+		t.Fatal(err)
+	}
+	if port := svc.Spec.Ports[0].TargetPort.IntValue(); port != 8080 {
+		t.Fatalf("expected the warmup Service to target 8080, got %d", port)
+	}
+}
+
+func TestMIGResourceInContainerLimits(t *testing.T) {
+	ms := newTestModelServe("mig")
+	gpuCount := int32(1)
+	ms.Spec.GPUCount = &gpuCount
+	ms.Spec.GPUResourceName = "nvidia.com/mig-1g.5gb"
+	r := newTestReconciler(t, ms)
+	reconcileUntilStable(t, r, "mig")
+
+	limits := getDeployment(t, r, "mig").Spec.Template.Spec.Containers[0].Resources.Limits
+	if q, ok := limits["nvidia.com/mig-1g.5gb"]; !ok || q.Value() != 1 {
+		t.Fatalf("expected one nvidia.com/mig-1g.5gb in the limits, got %v", limits)
+	}
+	if _, ok := limits["nvidia.com/gpu"]; ok {
+		t.Fatalf("expected no whole GPU request alongside the MIG slice, got %v", limits)
+	}
+}
+
+func TestGPUSchedulingConstraintsStayOnServer(t *testing.T) {
+	ms := newTestModelServe("gpu-sched")
+	gpuCount := int32(1)
+	ms.Spec.GPUCount = &gpuCount
+	ms.Spec.StartupScript = "echo ready"
+	ms.Spec.NodeSelector = map[string]string{"gpu": "a100"}
+	r := newTestReconciler(t, ms)
+	reconcileUntilStable(t, r, "gpu-sched")
+
+	podSpec := getDeployment(t, r, "gpu-sched").Spec.Template.Spec
+	if q := podSpec.Containers[0].Resources.Limits["nvidia.com/gpu"]; q.Value() != 1 {
+		t.Fatalf("expected the server to request the GPU, got %v", podSpec.Containers[0].Resources.Limits)
+	}
+	for _, c := range append(podSpec.InitContainers, podSpec.Containers[1:]...) {
+		if _, ok := c.Resources.Limits["nvidia.com/gpu"]; ok {
+			t.Fatalf("expected %s not to request a GPU", c.Name)
+		}
+		if _, ok := c.Resources.Requests["nvidia.com/gpu"]; ok {
+			t.Fatalf("expected %s not to request a GPU", c.Name)
+		}
+	}
+	if len(podSpec.Tolerations) != 1 || podSpec.Tolerations[0].Key != "nvidia.com/gpu" ||
+		podSpec.Tolerations[0].Operator != corev1.TolerationOpExists || podSpec.Tolerations[0].Effect != corev1.TaintEffectNoSchedule {
+		t.Fatalf("expected the pod to tolerate the GPU node taint, got %v", podSpec.Tolerations)
+	}
+	if podSpec.NodeSelector["gpu"] != "a100" || podSpec.Affinity != nil {
+		t.Fatalf("expected only the node selector of the spec, got %v and %v", podSpec.NodeSelector, podSpec.Affinity)
+	}
+
+	// Models without GPUs do not tolerate the GPU taint
+	cpuModel := newTestModelServe("cpu-sched")
+	r = newTestReconciler(t, cpuModel)
+	reconcileUntilStable(t, r, "cpu-sched")
+	if tolerations := getDeployment(t, r, "cpu-sched").Spec.Template.Spec.Tolerations; len(tolerations) != 0 {
+		t.Fatalf("expected no tolerations without GPUs, got %v", tolerations)
+	}
+}
+
+func TestModelMountedReadOnlyInServer(t *testing.T) {
+	ms := newTestModelServe("readonly")
+	r := newTestReconciler(t, ms)
+	reconcileUntilStable(t, r, "readonly")
+
+	podSpec := getDeployment(t, r, "readonly").Spec.Template.Spec
+	for _, c := range append(podSpec.InitContainers, podSpec.Containers[0]) {
+		for _, vm := range c.VolumeMounts {
+			if vm.Name != "model-volume" {
+				continue
+			}
+			if wantReadOnly := c.Name == "llama-server"; vm.ReadOnly != wantReadOnly {
+				t.Fatalf("container %s: expected model volume read-only %v, got %v", c.Name, wantReadOnly, vm.ReadOnly)
+			}
+		}
+	}
+}
+
+func TestExtraPortsOnContainerAndService(t *testing.T) {
+	ms := newTestModelServe("ports")
+	ms.Spec.ExtraPorts = []corev1.ContainerPort{{Name: "grpc", ContainerPort: 50051}}
+	r := newTestReconciler(t, ms)
+	reconcileUntilStable(t, r, "ports")
+
+	ports := getDeployment(t, r, "ports").Spec.Template.Spec.Containers[0].Ports
+	if len(ports) != 2 || ports[1].Name != "grpc" || ports[1].ContainerPort != 50051 {
+		t.Fatalf("expected the grpc port on the server container, got %+v", ports)
+	}
+
+	svc := &corev1.Service{}
+	if err := r.Get(context.Background(), types.NamespacedName{Name: "ports", Namespace: "default"}, svc); err != nil {
+		t.Fatalf("get service: %v", err)
+	}
+	if len(svc.Spec.Ports) != 2 || svc.Spec.Ports[1].Name != "grpc" || svc.Spec.Ports[1].Port != 50051 ||
+		svc.Spec.Ports[1].TargetPort.IntValue() != 50051 {
+		t.Fatalf("expected the grpc port mirrored on the Service, got %+v", svc.Spec.Ports)
+	}
+}
+
+func TestForcedImageOverridesSpec(t *testing.T) {
+	t.Setenv("FORCE_IMAGE", "registry.internal/llama.cpp:vetted")
+
+	ms := newTestModelServe("forced")
+	ms.Spec.Image = "ghcr.io/ggerganov/llama.cpp:latest"
+	r := newTestReconciler(t, ms)
+	reconcileUntilStable(t, r, "forced")
+
+	if image := getDeployment(t, r, "forced").Spec.Template.Spec.Containers[0].Image; image != "registry.internal/llama.cpp:vetted" {
+		t.Fatalf("expected the forced image, got %q", image)
+	}
+	if err := r.Get(context.Background(), types.NamespacedName{Name: "forced", Namespace: "default"}, ms); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(ms.Status.ImageOverride, "overrides ghcr.io/ggerganov/llama.cpp:latest") {
+		t.Fatalf("expected status to record the override, got %q", ms.Status.ImageOverride)
+	}
+}
+
+func TestGPUSharingGroupAffinity(t *testing.T) {
+	var pods []corev1.PodTemplateSpec
+	for _, name := range []string{"share-a", "share-b"} {
+		ms := newTestModelServe(name)
+		gpuCount := int32(1)
+		ms.Spec.GPUCount = &gpuCount
+		ms.Spec.GPUResourceName = "nvidia.com/gpu.shared"
+		ms.Spec.GPUSharing = &modelv1alpha1.GPUSharingSpec{Group: "small-models"}
+		r := newTestReconciler(t, ms)
+		reconcileUntilStable(t, r, name)
+		pods = append(pods, getDeployment(t, r, name).Spec.Template)
+	}
+
+	// Each pod prefers the node running the other model of the group
+	for i, pod := range pods {
+		if pod.Labels[gpuSharingGroupLabel] != "small-models" {
+			t.Fatalf("expected the group label on the pod, got %v", pod.Labels)
+		}
+		affinity := pod.Spec.Affinity
+		if affinity == nil || affinity.PodAffinity == nil || len(affinity.PodAffinity.PreferredDuringSchedulingIgnoredDuringExecution) != 1 {
+			t.Fatalf("expected a preferred pod affinity, got %+v", affinity)
+		}
+		term := affinity.PodAffinity.PreferredDuringSchedulingIgnoredDuringExecution[0].PodAffinityTerm
+		if term.TopologyKey != "kubernetes.io/hostname" ||
+			!labels.SelectorFromSet(term.LabelSelector.MatchLabels).Matches(labels.Set(pods[1-i].Labels)) {
+			t.Fatalf("expected the affinity to select the group on the same node, got %+v", term)
+		}
+	}
+}
+
+func TestInvalidStorageSizeFailsModel(t *testing.T) {
+	ms := newTestModelServe("badsize")
+	ms.Spec.Storage = &modelv1alpha1.StorageSpec{Size: "20 GB"}
+	r := newTestReconciler(t, ms)
+	reconcileUntilStable(t, r, "badsize")
+
+	status := getModelServe(t, r, "badsize").Status
+	if status.Phase != "Failed" || status.FailureReason != failureInvalidStorage || !strings.Contains(status.Message, "20 GB") {
+		t.Fatalf("expected the invalid size to fail the model, got %s/%s: %s", status.Phase, status.FailureReason, status.Message)
+	}
+	key := types.NamespacedName{Name: "badsize", Namespace: "default"}
+	if err := r.Get(context.Background(), key, &appsv1.Deployment{}); !errors.IsNotFound(err) {
+		t.Fatalf("expected no Deployment for an invalid size, got %v", err)
+	}
+
+	// Fixing the size resumes the model
+	ms = getModelServe(t, r, "badsize")
+	ms.Spec.Storage.Size = "20Gi"
+	if err := r.Update(context.Background(), ms); err != nil {
+		t.Fatal(err)
+	}
+	reconcileUntilStable(t, r, "badsize")
+	if reason := getModelServe(t, r, "badsize").Status.FailureReason; reason != "" {
+		t.Fatalf("expected the failure to clear, got %q", reason)
+	}
+	if err := r.Get(context.Background(), types.NamespacedName{Name: "badsize-model", Namespace: "default"}, &corev1.PersistentVolumeClaim{}); err != nil {
+		t.Fatalf("expected the model claim once the size is valid: %v", err)
+	}
+}
+
+func TestVerifyOnStartRedownloadsCorruptModel(t *testing.T) {
+	sum := strings.Repeat("ab", 32)
+	ms := newTestModelServe("verify")
+	ms.Spec.Storage = &modelv1alpha1.StorageSpec{Size: "20Gi"}
+	ms.Spec.Download = &modelv1alpha1.DownloadSpec{SHA256: sum, VerifyOnStart: true}
+	r := newTestReconciler(t, ms)
+	reconcileUntilStable(t, r, "verify")
+
+	script := getDeployment(t, r, "verify").Spec.Template.Spec.InitContainers[0].Args[0]
+	check := `echo "` + sum + `  /models/verify.gguf" | sha256sum -c -`
+
+	// The existing file is verified first, removed on mismatch, then downloaded
+	// and verified again
+	steps := []string{
+		"if [ -f /models/verify.gguf ]",
+		"if " + check,
+		"exit 0",
+		"rm -f /models/verify.gguf",
+		"mc cp minio/",
+		"if ! " + check,
+		"exit 1",
+	}
+	rest := script
+	for _, step := range steps {
+		i := strings.Index(rest, step)
+		if i < 0 {
+			t.Fatalf("expected %q after the previous step in the init script:\n%s", step, script)
+		}
+		rest = rest[i+len(step):]
+	}
+}
+
+func TestSyncWaveAnnotationPropagates(t *testing.T) {
+	ms := newTestModelServe("wave")
+	ms.Annotations = map[string]string{
+		"argocd.argoproj.io/sync-wave": "2",
+		"example.com/unrelated":        "x",
+	}
+	r := newTestReconciler(t, ms)
+	reconcileUntilStable(t, r, "wave")
+
+	key := types.NamespacedName{Name: "wave", Namespace: "default"}
+	for _, obj := range []client.Object{&appsv1.Deployment{}, &corev1.Service{}, &networkingv1.Ingress{}} {
+		if err := r.Get(context.Background(), key, obj); err != nil {
+			t.Fatalf("get %T: %v", obj, err)
+		}
+		annotations := obj.GetAnnotations()
+		if annotations["argocd.argoproj.io/sync-wave"] != "2" {
+			t.Fatalf("expected the sync wave on the %T, got %v", obj, annotations)
+		}
+		if _, ok := annotations["example.com/unrelated"]; ok {
+			t.Fatalf("expected only configured prefixes to propagate to the %T, got %v", obj, annotations)
+		}
+	}
+
+	// Another tool annotates the Service
+	svc := &corev1.Service{}
+	if err := r.Get(context.Background(), key, svc); err != nil {
+		t.Fatal(err)
+	}
+	svc.Annotations["example.com/owner"] = "mesh"
+	if err := r.Update(context.Background(), svc); err != nil {
+		t.Fatal(err)
+	}
+
+	// Dropping the sync wave from the ModelServe drops it everywhere
+	ms = getModelServe(t, r, "wave")
+	delete(ms.Annotations, "argocd.argoproj.io/sync-wave")
+	if err := r.Update(context.Background(), ms); err != nil {
+		t.Fatal(err)
+	}
+	reconcileUntilStable(t, r, "wave")
+	for _, obj := range []client.Object{&appsv1.Deployment{}, &corev1.Service{}, &networkingv1.Ingress{}} {
+		if err := r.Get(context.Background(), key, obj); err != nil {
+			t.Fatalf("get %T: %v", obj, err)
+		}
+		if wave, ok := obj.GetAnnotations()["argocd.argoproj.io/sync-wave"]; ok {
+			t.Fatalf("expected the removed sync wave to leave the %T, got %q", obj, wave)
+		}
+	}
+	if err := r.Get(context.Background(), key, svc); err != nil {
+		t.Fatal(err)
+	}
+	if svc.Annotations["example.com/owner"] != "mesh" {
+		t.Fatalf("expected annotations of other tools to be kept, got %v", svc.Annotations)
+	}
+}
+
+func TestQuantizationSelectsModelVariant(t *testing.T) {
+	ms := newTestModelServe("quant")
+	ms.Spec.ModelName = "llama-3-8b.gguf"
+	ms.Spec.MinIOPath = ""
+	ms.Spec.Quantization = "Q4_K_M"
+	r := newTestReconciler(t, ms)
+	reconcileUntilStable(t, r, "quant")
+
+	podSpec := getDeployment(t, r, "quant").Spec.Template.Spec
+	script := podSpec.InitContainers[0].Args[0]
+	if !strings.Contains(script, "mc cp minio/inference-models/models/llama-3-8b.Q4_K_M.gguf /models/llama-3-8b.Q4_K_M.gguf") {
+		t.Fatalf("expected the Q4_K_M variant to be downloaded, got script:\n%s", script)
+	}
+	args := strings.Join(podSpec.Containers[0].Args, " ")
+	if !strings.Contains(args, "-m /models/llama-3-8b.Q4_K_M.gguf") {
+		t.Fatalf("expected the server to load the Q4_K_M variant, got args %q", args)
+	}
+}
+
+func TestDeploymentAnnotationsLeavePodTemplate(t *testing.T) {
+	ms := newTestModelServe("depann")
+	ms.Spec.DeploymentAnnotations = map[string]string{
+		"reloader.example.io/match":         "true",
+		"deployment.kubernetes.io/revision": "99",
+		configHashAnnotation:                "forged",
+	}
+	ms.Spec.ReplicaGroups = []modelv1alpha1.ReplicaGroup{{Name: "small", Replicas: 1}}
+	r := newTestReconciler(t, ms)
+	reconcileUntilStable(t, r, "depann")
+
+	dep := getDeployment(t, r, "depann")
+	if dep.Annotations["reloader.example.io/match"] != "true" {
+		t.Fatalf("expected deployment annotation on the Deployment, got %v", dep.Annotations)
+	}
+	if _, ok := dep.Annotations["deployment.kubernetes.io/revision"]; ok {
+		t.Fatalf("expected managed annotation to be dropped, got %v", dep.Annotations)
+	}
+	if _, ok := dep.Annotations[configHashAnnotation]; ok {
+		t.Fatalf("expected operator annotation to be dropped, got %v", dep.Annotations)
+	}
+	if _, ok := dep.Spec.Template.Annotations["reloader.example.io/match"]; ok {
+		t.Fatalf("expected pod template annotations untouched, got %v", dep.Spec.Template.Annotations)
+	}
+
+	// Later additions reach the existing Deployment
+	key := types.NamespacedName{Name: "depann", Namespace: "default"}
+	if err := r.Get(context.Background(), key, ms); err != nil {
+		t.Fatal(err)
+	}
+	ms.Spec.DeploymentAnnotations["team"] = "ml"
+	if err := r.Update(context.Background(), ms); err != nil {
+		t.Fatal(err)
+	}
+	reconcileUntilStable(t, r, "depann")
+	if getDeployment(t, r, "depann").Annotations["team"] != "ml" {
+		t.Fatal("expected new deployment annotation to be synced")
+	}
+
+	// and removals too, keeping what the operator set itself
+	if err := r.Get(context.Background(), key, ms); err != nil {
+		t.Fatal(err)
+	}
+	delete(ms.Spec.DeploymentAnnotations, "reloader.example.io/match")
+	if err := r.Update(context.Background(), ms); err != nil {
+		t.Fatal(err)
+	}
+	reconcileUntilStable(t, r, "depann")
+	for _, name := range []string{"depann", "depann-small"} {
+		dep = getDeployment(t, r, name)
+		if _, ok := dep.Annotations["reloader.example.io/match"]; ok {
+			t.Fatalf("%s: expected removed deployment annotation to be dropped, got %v", name, dep.Annotations)
+		}
+		if dep.Annotations["team"] != "ml" || dep.Annotations[templateHashAnnotation] == "" {
+			t.Fatalf("%s: expected the remaining annotations to be kept, got %v", name, dep.Annotations)
+		}
+	}
+}
+
+// conflictingStatusClient fails the first ModelServe status update with a
+// conflict after a concurrent writer changed the object
+type conflictingStatusClient struct {
+	client.Client
+	conflicts int
+}
+
+func (c *conflictingStatusClient) Status() client.SubResourceWriter {
+	return &conflictingStatusWriter{SubResourceWriter: c.Client.Status(), c: c}
+}
+
+type conflictingStatusWriter struct {
+	client.SubResourceWriter
+	c *conflictingStatusClient
+}
+
+func (w *conflictingStatusWriter) Update(ctx context.Context, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+	if ms, ok := obj.(*modelv1alpha1.ModelServe); ok && w.c.conflicts == 0 {
+		w.c.conflicts++
+
+		current := &modelv1alpha1.ModelServe{}
+		if err := w.c.Get(ctx, client.ObjectKeyFromObject(ms), current); err != nil {
+			return err
+		}
+		current.Annotations = map[string]string{"touched-by": "someone-else"}
+		if err := w.c.Update(ctx, current); err != nil {
+			return err
+		}
+		return errors.NewConflict(schema.GroupResource{Group: "model.example.com", Resource: "modelserves"}, ms.Name, nil)
+	}
+	return w.SubResourceWriter.Update(ctx, obj, opts...)
+}
+
+func TestInitialStatusConflictIsRetried(t *testing.T) {
+	r := newTestReconciler(t, newTestModelServe("race"))
+	c := &conflictingStatusClient{Client: r.Client}
+	r.Client = c
+
+	reconcileUntilStable(t, r, "race")
+
+	if c.conflicts != 1 {
+		t.Fatalf("expected the initial status update to hit one conflict, got %d", c.conflicts)
+	}
+	ms := getModelServe(t, r, "race")
+	if ms.Status.Phase != "Downloading" {
+		t.Fatalf("expected the phase to advance to Downloading after the conflict, got %q", ms.Status.Phase)
+	}
+	if ms.Annotations["touched-by"] != "someone-else" {
+		t.Fatalf("expected the concurrent change to survive, got %v", ms.Annotations)
+	}
+	getDeployment(t, r, "race")
+}
+
+func TestRouteWhenReadyDefersIngress(t *testing.T) {
+	ms := newTestModelServe("ready")
+	ms.Spec.RouteWhenReady = true
+	r := newTestReconciler(t, ms)
+	reconcileUntilStable(t, r, "ready")
+
+	key := types.NamespacedName{Name: "ready", Namespace: "default"}
+	if err := r.Get(context.Background(), key, &networkingv1.Ingress{}); !errors.IsNotFound(err) {
+		t.Fatalf("expected no Ingress while the model is starting, got %v", err)
+	}
+	if phase := getModelServe(t, r, "ready").Status.Phase; phase == "Running" {
+		t.Fatalf("expected the model not to be running yet, got %q", phase)
+	}
+	// The Service exists so the route works as soon as it is created
+	if err := r.Get(context.Background(), key, &corev1.Service{}); err != nil {
+		t.Fatalf("expected the Service to be created: %v", err)
+	}
+
+	dep := getDeployment(t, r, "ready")
+	dep.Status.AvailableReplicas = 1
+	if err := r.Update(context.Background(), dep); err != nil {
+		t.Fatal(err)
+	}
+	reconcileUntilStable(t, r, "ready")
+
+	if err := r.Get(context.Background(), key, &networkingv1.Ingress{}); err != nil {
+		t.Fatalf("expected the Ingress once the model is running: %v", err)
+	}
+	if phase := getModelServe(t, r, "ready").Status.Phase; phase != "Running" {
+		t.Fatalf("expected the model to be running, got %q", phase)
+	}
+}
+
+func TestWarmPoolFloorsScaleDown(t *testing.T) {
+	ms := newTestModelServe("warmpool")
+	replicas := int32(4)
+	ms.Spec.Replicas = &replicas
+	ms.Spec.WarmPool = 2
+	r := newTestReconciler(t, ms)
+	reconcileUntilStable(t, r, "warmpool")
+
+	if got := *getDeployment(t, r, "warmpool").Spec.Replicas; got != 4 {
+		t.Fatalf("expected 4 replicas above the warm pool, got %d", got)
+	}
+	if selector := getModelServe(t, r, "warmpool").Status.Selector; selector != "app=model-serve,model_serve_cr=warmpool" {
+		t.Fatalf("expected the pod selector in status for the scale subresource, got %q", selector)
+	}
+
+	// An autoscaler scales the idle model to zero through spec.replicas
+	for _, want := range []struct{ replicas, deployment int32 }{{1, 2}, {0, 2}, {3, 3}} {
+		ms := getModelServe(t, r, "warmpool")
+		scaled := want.replicas
+		ms.Spec.Replicas = &scaled
+		if err := r.Update(context.Background(), ms); err != nil {
+			t.Fatal(err)
+		}
+		reconcileUntilStable(t, r, "warmpool")
+
+		if got := *getDeployment(t, r, "warmpool").Spec.Replicas; got != want.deployment {
+			t.Fatalf("scaled to %d: expected %d replicas, got %d", want.replicas, want.deployment, got)
+		}
+	}
+}
+
+func TestAppliedMiddlewaresMatchIngressChain(t *testing.T) {
+	r := newTestReconciler(t, newTestModelServe("chain"))
+	reconcileUntilStable(t, r, "chain")
+
+	ing := &networkingv1.Ingress{}
+	if err := r.Get(context.Background(), types.NamespacedName{Name: "chain", Namespace: "default"}, ing); err != nil {
+		t.Fatal(err)
+	}
+	applied := getModelServe(t, r, "chain").Status.AppliedMiddlewares
+	if got := strings.Join(applied, ","); got != ing.Annotations[middlewaresAnnotation] {
+		t.Fatalf("expected status middlewares %q to match the Ingress chain %q", got, ing.Annotations[middlewaresAnnotation])
+	}
+	want := []string{"default-jwt-auth@kubernetescrd", "default-chain-stripprefix@kubernetescrd"}
+	if !equality.Semantic.DeepEqual(applied, want) {
+		t.Fatalf("expected middlewares %v in order, got %v", want, applied)
+	}
+}
+
+func TestPublicModelUpdatesIngressMiddlewares(t *testing.T) {
+	r := newTestReconciler(t, newTestModelServe("open"))
+	reconcileUntilStable(t, r, "open")
+
+	ms := getModelServe(t, r, "open")
+	ms.Spec.Public = true
+	if err := r.Update(context.Background(), ms); err != nil {
+		t.Fatal(err)
+	}
+	reconcileUntilStable(t, r, "open")
+
+	ing := &networkingv1.Ingress{}
+	if err := r.Get(context.Background(), types.NamespacedName{Name: "open", Namespace: "default"}, ing); err != nil {
+		t.Fatal(err)
+	}
+	if got := ing.Annotations[middlewaresAnnotation]; got != "default-open-stripprefix@kubernetescrd" {
+		t.Fatalf("expected the public route to drop JWT auth, got %q", got)
+	}
+	applied := getModelServe(t, r, "open").Status.AppliedMiddlewares
+	if !equality.Semantic.DeepEqual(applied, []string{"default-open-stripprefix@kubernetescrd"}) {
+		t.Fatalf("expected the status to follow the Ingress, got %v", applied)
+	}
+}
+
+func TestStripPrefixMiddlewareFollowsRoutePath(t *testing.T) {
+	ms := newTestModelServe("strip")
+	ms.Spec.RoutePath = "/v1/strip"
+	r := newTestReconciler(t, ms)
+	reconcileUntilStable(t, r, "strip")
+
+	key := types.NamespacedName{Name: "strip-stripprefix", Namespace: "default"}
+	before := &corev1.ConfigMap{}
+	if err := r.Get(context.Background(), key, before); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(before.Data["middleware.yaml"], "- /v1/strip\n") {
+		t.Fatalf("expected the route path prefix, got %q", before.Data["middleware.yaml"])
+	}
+
+	ms = getModelServe(t, r, "strip")
+	ms.Spec.RoutePath = "/v2/strip"
+	if err := r.Update(context.Background(), ms); err != nil {
+		t.Fatal(err)
+	}
+	reconcileUntilStable(t, r, "strip")
+
+	after := &corev1.ConfigMap{}
+	if err := r.Get(context.Background(), key, after); err != nil {
+		t.Fatal(err)
+	}
+	if after.UID != before.UID {
+		t.Fatal("expected the middleware ConfigMap to be updated in place")
+	}
+	if got := after.Data["middleware.yaml"]; !strings.Contains(got, "- /v2/strip\n") || strings.Contains(got, "/v1/strip") {
+		t.Fatalf("expected the new route path prefix only, got %q", got)
+	}
+}
+
+func TestDownloadProxyEnvOnInitContainer(t *testing.T) {
+	ms := newTestModelServe("proxied")
+	ms.Spec.Download = &modelv1alpha1.DownloadSpec{
+		HTTPProxy:  "http://proxy.corp:3128",
+		HTTPSProxy: "http://proxy.corp:3128",
+		NoProxy:    "minio,.svc.cluster.local,10.0.0.0/8",
+	}
+	r := newTestReconciler(t, ms)
+	reconcileUntilStable(t, r, "proxied")
+
+	init := getDeployment(t, r, "proxied").Spec.Template.Spec.InitContainers[0]
+	env := map[string]string{}
+	for _, e := range init.Env {
+		env[e.Name] = e.Value
+	}
+	want := map[string]string{
+		"HTTP_PROXY":  "http://proxy.corp:3128",
+		"http_proxy":  "http://proxy.corp:3128",
+		"HTTPS_PROXY": "http://proxy.corp:3128",
+		"https_proxy": "http://proxy.corp:3128",
+		"NO_PROXY":    "minio,.svc.cluster.local,10.0.0.0/8",
+		"no_proxy":    "minio,.svc.cluster.local,10.0.0.0/8",
+	}
+	for name, value := range want {
+		if env[name] != value {
+			t.Fatalf("expected %s=%s on the init container, got %q", name, value, env[name])
+		}
+	}
+	if !hasEnv(&init, "MINIO_ACCESS_KEY") {
+		t.Fatal("expected the MinIO credentials next to the proxy settings")
+	}
+}
+
+func TestParallelSlotsReplaceRuntimeParams(t *testing.T) {
+	slots := int32(8)
+	ms := newTestModelServe("slots")
+	ms.Spec.ParallelSlots = &slots
+	ms.Spec.RuntimeParams = "--parallel 2 --threads 4 -np=3 --cont-batching"
+	r := newTestReconciler(t, ms)
+	reconcileUntilStable(t, r, "slots")
+
+	args := getDeployment(t, r, "slots").Spec.Template.Spec.Containers[0].Args
+	joined := strings.Join(args, " ")
+	if strings.Count(joined, "--parallel") != 1 || !strings.Contains(joined, "--parallel 8") {
+		t.Fatalf("expected a single --parallel 8, got %v", args)
+	}
+	if strings.Contains(joined, "-np") || strings.Contains(joined, " 2 ") {
+		t.Fatalf("expected the runtimeParams slots to be dropped, got %v", args)
+	}
+	if !strings.HasSuffix(joined, "--threads 4 --cont-batching") {
+		t.Fatalf("expected the other runtimeParams to be kept, got %v", args)
+	}
+}
+
+func TestSpecChangesRollRunningModel(t *testing.T) {
+	ms := newTestModelServe("rolling")
+	r := newTestReconciler(t, ms)
+	reconcileUntilStable(t, r, "rolling")
+	hash := getDeployment(t, r, "rolling").Annotations[templateHashAnnotation]
+	if hash == "" {
+		t.Fatal("expected the pod template hash on the Deployment")
+	}
+
+	// Fields that only change the pod template still reach the pods
+	slots := int32(4)
+	ms = getModelServe(t, r, "rolling")
+	ms.Spec.ParallelSlots = &slots
+	ms.Spec.ExtraPorts = []corev1.ContainerPort{{Name: "grpc", ContainerPort: 9000}}
+	if err := r.Update(context.Background(), ms); err != nil {
+		t.Fatal(err)
+	}
+	reconcileUntilStable(t, r, "rolling")
+
+	dep := getDeployment(t, r, "rolling")
+	if dep.Annotations[templateHashAnnotation] == hash {
+		t.Fatal("expected the template hash to change")
+	}
+	server := dep.Spec.Template.Spec.Containers[0]
+	if !strings.Contains(strings.Join(server.Args, " "), "--parallel 4") || len(server.Ports) != 2 {
+		t.Fatalf("expected the running Deployment to pick up the spec, got args %v and ports %v", server.Args, server.Ports)
+	}
+}
+
+func TestCompletionProbeGatesReadiness(t *testing.T) {
+	ms := newTestModelServe("completion")
+	ms.Spec.CompletionProbe = &modelv1alpha1.CompletionProbeSpec{Prompt: `Say "ok"`, TimeoutSeconds: 20}
+	r := newTestReconciler(t, ms)
+	reconcileUntilStable(t, r, "completion")
+
+	probe := getDeployment(t, r, "completion").Spec.Template.Spec.Containers[0].ReadinessProbe
+	if probe == nil || probe.Exec == nil || probe.HTTPGet != nil {
+		t.Fatalf("expected an exec readiness probe, got %+v", probe)
+	}
+	cmd := probe.Exec.Command
+	if len(cmd) != 6 || !strings.Contains(cmd[2], "/completion") {
+		t.Fatalf("expected the probe to call the completion endpoint, got %v", cmd)
+	}
+	var body struct {
+		Prompt string `json:"prompt"`
+	}
+	if err := json.Unmarshal([]byte(cmd[4]), &body); err != nil || body.Prompt != `Say "ok"` {
+		t.Fatalf("expected the configured prompt in the request body, got %q (%v)", cmd[4], err)
+	}
+	if cmd[5] != "20" || probe.TimeoutSeconds <= 20 {
+		t.Fatalf("expected the configured timeout, got curl %s and probe %d", cmd[5], probe.TimeoutSeconds)
+	}
+}
+
+func TestCustomDownloadCommandSubstitutesVariables(t *testing.T) {
+	ms := newTestModelServe("custom")
+	ms.Spec.MinIOEndpoint = "store.example.com:9000"
+	ms.Spec.MinIOBucket = "weights"
+	ms.Spec.Download = &modelv1alpha1.DownloadSpec{
+		Command: "fetch-model --name {{.ModelName}} --from https://{{.Endpoint}}/{{.Bucket}}/{{.Path}} --to {{.Dest}}",
+		Image:   "example.com/fetcher:1",
+	}
+
+	// Without the cluster flag the built-in download is kept
+	r := newTestReconciler(t, ms)
+	reconcileUntilStable(t, r, "custom")
+	init := getDeployment(t, r, "custom").Spec.Template.Spec.InitContainers[0]
+	if !strings.Contains(init.Args[0], "mc cp minio/") || strings.Contains(init.Args[0], "fetch-model") {
+		t.Fatalf("expected the built-in download without %s, got:\n%s", modelv1alpha1.CustomDownloadCommandEnv, init.Args[0])
+	}
+
+	t.Setenv(modelv1alpha1.CustomDownloadCommandEnv, "true")
+	r = newTestReconciler(t, ms.DeepCopy())
+	reconcileUntilStable(t, r, "custom")
+	init = getDeployment(t, r, "custom").Spec.Template.Spec.InitContainers[0]
+
+	want := "fetch-model --name custom.gguf --from https://store.example.com:9000/weights/models/custom.gguf --to /models/custom.gguf"
+	if !strings.Contains(init.Args[0], want) {
+		t.Fatalf("expected the substituted command %q in the init script:\n%s", want, init.Args[0])
+	}
+	if strings.Contains(init.Args[0], "mc cp") {
+		t.Fatalf("expected the custom command to replace the built-in download:\n%s", init.Args[0])
+	}
+	if init.Image != "example.com/fetcher:1" {
+		t.Fatalf("expected the download image from the spec, got %q", init.Image)
+	}
+}
+
+func TestScaleToZeroClearsPodName(t *testing.T) {
+	ms := newTestModelServe("idle")
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "idle-abc", Namespace: "default", Labels: labelsForModelServe("idle")},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	r := newTestReconciler(t, ms, pod)
+	reconcileUntilStable(t, r, "idle")
+
+	dep := getDeployment(t, r, "idle")
+	dep.Status.AvailableReplicas = 1
+	if err := r.Update(context.Background(), dep); err != nil {
+		t.Fatal(err)
+	}
+	reconcileUntilStable(t, r, "idle")
+	if status := getModelServe(t, r, "idle").Status; status.PodName != "idle-abc" || status.StartedAt == nil {
+		t.Fatalf("expected the running pod in status, got %q started %v", status.PodName, status.StartedAt)
+	}
+
+	// Scale to zero; the pod goes away with the last replica
+	zero := int32(0)
+	ms = getModelServe(t, r, "idle")
+	ms.Spec.Replicas = &zero
+	if err := r.Update(context.Background(), ms); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Delete(context.Background(), pod); err != nil {
+		t.Fatal(err)
+	}
+	dep = getDeployment(t, r, "idle")
+	dep.Status.AvailableReplicas = 0
+	if err := r.Status().Update(context.Background(), dep); err != nil {
+		t.Fatal(err)
+	}
+	reconcileUntilStable(t, r, "idle")
+
+	status := getModelServe(t, r, "idle").Status
+	if status.PodName != "" || status.StartedAt != nil {
+		t.Fatalf("expected the pod reference to be cleared, got %q started %v", status.PodName, status.StartedAt)
+	}
+	if status.Phase == "Running" {
+		t.Fatalf("expected a model without pods not to be running, got %q", status.Phase)
+	}
+}
+
+func TestMinReadySecondsOnDeployment(t *testing.T) {
+	ms := newTestModelServe("stable")
+	r := newTestReconciler(t, ms)
+	reconcileUntilStable(t, r, "stable")
+
+	if got := getDeployment(t, r, "stable").Spec.MinReadySeconds; got != 5 {
+		t.Fatalf("expected the default minReadySeconds of 5, got %d", got)
+	}
+
+	// Changing the spec updates the existing Deployment
+	ms = getModelServe(t, r, "stable")
+	minReady := int32(30)
+	ms.Spec.MinReadySeconds = &minReady
+	if err := r.Update(context.Background(), ms); err != nil {
+		t.Fatal(err)
+	}
+	reconcileUntilStable(t, r, "stable")
+
+	if got := getDeployment(t, r, "stable").Spec.MinReadySeconds; got != 30 {
+		t.Fatalf("expected minReadySeconds 30 from the spec, got %d", got)
+	}
+}
+
+func TestChecksumObjectVerifiesDownload(t *testing.T) {
+	ms := newTestModelServe("rotating")
+	ms.Spec.MinIOBucket = "weights"
+	ms.Spec.Download = &modelv1alpha1.DownloadSpec{ChecksumObject: "models/rotating.gguf.sha256"}
+	r := newTestReconciler(t, ms)
+	reconcileUntilStable(t, r, "rotating")
+
+	script := getDeployment(t, r, "rotating").Spec.Template.Spec.InitContainers[0].Args[0]
+
+	// The checksum file is fetched after the model and compared against it
+	steps := []string{
+		"mc cp minio/weights/models/rotating.gguf /models/rotating.gguf",
+		"expected=$(mc cat minio/weights/models/rotating.gguf.sha256 | cut -d ' ' -f 1)",
+		`echo "$expected  /models/rotating.gguf" | sha256sum -c -`,
+		"rm -f /models/rotating.gguf",
+		"exit 1",
+	}
+	rest := script
+	for _, step := range steps {
+		i := strings.Index(rest, step)
+		if i < 0 {
+			t.Fatalf("expected %q after the previous step in the init script:\n%s", step, script)
+		}
+		rest = rest[i+len(step):]
+	}
+}
+
+func TestLastModifiedBySurfacedInStatus(t *testing.T) {
+	ms := newTestModelServe("audited")
+	ms.Annotations = map[string]string{modelv1alpha1.LastModifiedByAnnotation: "alice"}
+	r := newTestReconciler(t, ms)
+	reconcileUntilStable(t, r, "audited")
+
+	if by := getModelServe(t, r, "audited").Status.LastModifiedBy; by != "alice" {
+		t.Fatalf("expected lastModifiedBy alice, got %q", by)
+	}
+}
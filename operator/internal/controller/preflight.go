@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	modelv1alpha1 "github.com/example/model-operator/api/v1alpha1"
+)
+
+// preflightHashAnnotation records the download a preflight Job checked, so a
+// corrected spec runs a new preflight
+const preflightHashAnnotation = "model.example.com/preflight-hash"
+
+// preflightJobName is the name of the Job checking the model before deploying
+func preflightJobName(m *modelv1alpha1.ModelServe) string {
+	return m.Name + "-preflight"
+}
+
+// ensurePreflight runs the preflight Job of the model and reports whether it
+// passed, with a status message while it has not. A failed preflight is an
+// error; changing the download settings replaces the Job.
+func (r *ModelServeReconciler) ensurePreflight(ctx context.Context, m *modelv1alpha1.ModelServe) (bool, string, error) {
+	desired := preflightJob(m)
+
+	job := &batchv1.Job{}
+	err := r.Get(ctx, types.NamespacedName{Name: desired.Name, Namespace: m.Namespace}, job)
+	if err != nil && !errors.IsNotFound(err) {
+		return false, "", err
+	}
+
+	exists := err == nil
+
+	// The spec changed since the last preflight, start over
+	if exists && job.Annotations[preflightHashAnnotation] != desired.Annotations[preflightHashAnnotation] {
+		if err := r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !errors.IsNotFound(err) {
+			return false, "", err
+		}
+		exists = false
+	}
+
+	if !exists {
+		if err := ctrl.SetControllerReference(m, desired, r.Scheme); err != nil {
+			return false, "", err
+		}
+		if err := r.Create(ctx, desired); err != nil && !errors.IsAlreadyExists(err) {
+			return false, "", err
+		}
+		r.Recorder.Event(m, corev1.EventTypeNormal, "Preflighting", "Checking the model download before deploying")
+		return false, "Checking the model download before deploying", nil
+	}
+
+	for _, cond := range job.Status.Conditions {
+		if cond.Type == batchv1.JobFailed && cond.Status == corev1.ConditionTrue {
+			return false, "", fmt.Errorf("preflight job %s failed: %s", job.Name, cond.Message)
+		}
+	}
+	if job.Status.Succeeded > 0 {
+		return true, "", nil
+	}
+	return false, "Checking the model download before deploying", nil
+}
+
+// preflightJob returns a CPU only Job downloading the model to a scratch
+// volume and checking its checksum, if set, and its GGUF header
+func preflightJob(m *modelv1alpha1.ModelServe) *batchv1.Job {
+	job := downloadJob(m, preflightJobName(m),
+		map[string]string{"app": "model-preflight", "model_serve_cr": m.Name},
+		"", modelFileName(m))
+
+	podSpec := &job.Spec.Template.Spec
+	podSpec.Volumes = []corev1.Volume{{
+		Name:         "model-volume",
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	}}
+
+	dest := "/models/" + modelFileName(m)
+	checks := fmt.Sprintf(`
+echo "Checking GGUF header..."
+if [ "$(head -c 4 %[1]s)" != "GGUF" ]; then
+  echo "%[1]s is not a GGUF model"
+  exit 1
+fi
+`, dest)
+	if m.Spec.Download != nil && m.Spec.Download.SHA256 != "" {
+		checks = fmt.Sprintf(`
+echo "Verifying model checksum..."
+echo "%s  %s" | sha256sum -c -
+`, m.Spec.Download.SHA256, dest) + checks
+	} else if m.Spec.Download != nil && m.Spec.Download.ChecksumObject != "" {
+		checks = checksumObjectVerification(m, dest) + checks
+	}
+	container := &podSpec.Containers[0]
+	container.Args[0] += checks + "echo \"Preflight passed\"\n"
+
+	// A failed check is a misconfiguration, retrying does not fix it
+	backoffLimit := int32(0)
+	job.Spec.BackoffLimit = &backoffLimit
+	podSpec.RestartPolicy = corev1.RestartPolicyNever
+
+	sum := sha256.Sum256([]byte(container.Image + "\n" + strings.Join(container.Args, "\n")))
+	job.Annotations = map[string]string{preflightHashAnnotation: hex.EncodeToString(sum[:])[:16]}
+	return job
+}
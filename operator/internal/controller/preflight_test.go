@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestPreflightRunsBeforeDeployment(t *testing.T) {
+	ms := newTestModelServe("checked")
+	ms.Spec.Preflight = true
+	gpuCount := int32(1)
+	ms.Spec.GPUCount = &gpuCount
+	r := newTestReconciler(t, ms)
+	reconcileUntilStable(t, r, "checked")
+
+	key := types.NamespacedName{Name: "checked", Namespace: "default"}
+	if err := r.Get(context.Background(), key, &appsv1.Deployment{}); !errors.IsNotFound(err) {
+		t.Fatalf("expected no Deployment before the preflight passed, got %v", err)
+	}
+	if phase := getModelServe(t, r, "checked").Status.Phase; phase != "Preflighting" {
+		t.Fatalf("expected phase Preflighting, got %q", phase)
+	}
+
+	job := &batchv1.Job{}
+	if err := r.Get(context.Background(), types.NamespacedName{Name: "checked-preflight", Namespace: "default"}, job); err != nil {
+		t.Fatalf("expected the preflight Job: %v", err)
+	}
+	podSpec := job.Spec.Template.Spec
+	if podSpec.Volumes[0].EmptyDir == nil {
+		t.Fatalf("expected the preflight to download to a scratch volume, got %+v", podSpec.Volumes[0])
+	}
+	if _, ok := podSpec.Containers[0].Resources.Limits["nvidia.com/gpu"]; ok {
+		t.Fatal("expected the preflight not to request a GPU")
+	}
+	if script := podSpec.Containers[0].Args[0]; !strings.Contains(script, "mc cp minio/") || !strings.Contains(script, `"GGUF"`) {
+		t.Fatalf("expected the preflight to download the model and check its header:\n%s", script)
+	}
+
+	job.Status.Succeeded = 1
+	if err := r.Update(context.Background(), job); err != nil {
+		t.Fatal(err)
+	}
+	reconcileUntilStable(t, r, "checked")
+
+	if err := r.Get(context.Background(), key, &appsv1.Deployment{}); err != nil {
+		t.Fatalf("expected the Deployment once the preflight passed: %v", err)
+	}
+}
+
+func TestFailedPreflightFailsModelServe(t *testing.T) {
+	ms := newTestModelServe("broken")
+	ms.Spec.Preflight = true
+	r := newTestReconciler(t, ms)
+	r.MinIODialer = func(context.Context, string) error { return nil }
+	reconcileUntilStable(t, r, "broken")
+
+	job := &batchv1.Job{}
+	if err := r.Get(context.Background(), types.NamespacedName{Name: "broken-preflight", Namespace: "default"}, job); err != nil {
+		t.Fatal(err)
+	}
+	job.Status.Conditions = []batchv1.JobCondition{{
+		Type:    batchv1.JobFailed,
+		Status:  corev1.ConditionTrue,
+		Message: "Job has reached the specified backoff limit",
+	}}
+	if err := r.Update(context.Background(), job); err != nil {
+		t.Fatal(err)
+	}
+	reconcileUntilStable(t, r, "broken")
+
+	status := getModelServe(t, r, "broken").Status
+	if status.Phase != "Failed" || !strings.Contains(status.Message, "preflight job broken-preflight failed") {
+		t.Fatalf("expected the failed preflight in status, got %q (%q)", status.Phase, status.Message)
+	}
+	if err := r.Get(context.Background(), types.NamespacedName{Name: "broken", Namespace: "default"}, &appsv1.Deployment{}); !errors.IsNotFound(err) {
+		t.Fatalf("expected no Deployment after a failed preflight, got %v", err)
+	}
+
+	// Fixing the model location starts a new preflight
+	ms = getModelServe(t, r, "broken")
+	ms.Spec.MinIOPath = "models/fixed.gguf"
+	if err := r.Update(context.Background(), ms); err != nil {
+		t.Fatal(err)
+	}
+	reconcileUntilStable(t, r, "broken")
+	job = &batchv1.Job{}
+	if err := r.Get(context.Background(), types.NamespacedName{Name: "broken-preflight", Namespace: "default"}, job); err != nil {
+		t.Fatalf("expected a new preflight Job: %v", err)
+	}
+	if len(job.Status.Conditions) != 0 || !strings.Contains(job.Spec.Template.Spec.Containers[0].Args[0], "models/fixed.gguf") {
+		t.Fatalf("expected the preflight to check the fixed location, got %+v", job.Status)
+	}
+}
@@ -0,0 +1,146 @@
+package controller
+
+import (
+	"context"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	modelv1alpha1 "github.com/example/model-operator/api/v1alpha1"
+)
+
+// replicaGroupLabel names the replica group of a Deployment and its pods
+const replicaGroupLabel = "model.example.com/replica-group"
+
+// replicaGroupDeploymentName is the name of the Deployment of a replica group
+func replicaGroupDeploymentName(m *modelv1alpha1.ModelServe, g modelv1alpha1.ReplicaGroup) string {
+	return m.Name + "-" + g.Name
+}
+
+// reconcileReplicaGroups keeps one Deployment per spec.replicaGroups entry and
+// removes the Deployments of dropped groups. The group pods carry the model
+// labels, so the model Service balances over every group. It returns the
+// available replicas of the groups.
+func (r *ModelServeReconciler) reconcileReplicaGroups(ctx context.Context, m *modelv1alpha1.ModelServe, configHash string) (int32, error) {
+	var available int32
+	var conflicts []string
+	wanted := map[string]bool{}
+
+	for _, g := range m.Spec.ReplicaGroups {
+		dep := r.replicaGroupDeployment(m, g, configHash)
+		wanted[dep.Name] = true
+		if err := ctrl.SetControllerReference(m, dep, r.Scheme); err != nil {
+			return 0, err
+		}
+
+		found := &appsv1.Deployment{}
+		err := r.Get(ctx, types.NamespacedName{Name: dep.Name, Namespace: dep.Namespace}, found)
+		if errors.IsNotFound(err) {
+			if err := r.Create(ctx, dep); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		// Never take over the Deployment of another model, e.g. ModelServe
+		// llama-small next to group small of ModelServe llama
+		if !metav1.IsControlledBy(found, m) {
+			conflicts = append(conflicts, g.Name)
+			continue
+		}
+
+		annotationsChanged := syncAnnotations(found, deploymentAnnotationsForModelServe(m))
+		if *found.Spec.Replicas != *dep.Spec.Replicas ||
+			found.Annotations[templateHashAnnotation] != dep.Annotations[templateHashAnnotation] || annotationsChanged {
+			found.Spec.Replicas = dep.Spec.Replicas
+			found.Spec.Template = dep.Spec.Template
+			mergeAnnotations(found, map[string]string{templateHashAnnotation: dep.Annotations[templateHashAnnotation]})
+			if err := r.Update(ctx, found); err != nil {
+				return 0, err
+			}
+		}
+		available += found.Status.AvailableReplicas
+	}
+
+	groups := &appsv1.DeploymentList{}
+	if err := r.List(ctx, groups, client.InNamespace(m.Namespace),
+		client.MatchingLabels(labelsForModelServe(m.Name)), client.HasLabels{replicaGroupLabel}); err != nil {
+		return 0, err
+	}
+	for i := range groups.Items {
+		dep := &groups.Items[i]
+		if wanted[dep.Name] || !metav1.IsControlledBy(dep, m) {
+			continue
+		}
+		if err := r.Delete(ctx, dep); err != nil && !errors.IsNotFound(err) {
+			return 0, err
+		}
+	}
+
+	if !equality.Semantic.DeepEqual(m.Status.ReplicaGroupConflicts, conflicts) {
+		if len(conflicts) > 0 {
+			r.Recorder.Eventf(m, corev1.EventTypeWarning, "ReplicaGroupConflict",
+				"Deployments of replica groups %s belong to another object", strings.Join(conflicts, ", "))
+		}
+		m.Status.ReplicaGroupConflicts = conflicts
+		if err := r.updateStatus(ctx, m); err != nil {
+			return 0, err
+		}
+	}
+	return available, nil
+}
+
+// replicaGroupDeployment returns the Deployment of a replica group: the model
+// Deployment with the overrides of the group applied
+func (r *ModelServeReconciler) replicaGroupDeployment(m *modelv1alpha1.ModelServe, g modelv1alpha1.ReplicaGroup, configHash string) *appsv1.Deployment {
+	gm := m.DeepCopy()
+	replicas := g.Replicas
+	gm.Spec.Replicas = &replicas
+	gm.Spec.WarmPool = 0
+	if g.MemoryLimit > 0 {
+		gm.Spec.MemoryLimit = g.MemoryLimit
+	}
+	if g.CPULimit > 0 {
+		gm.Spec.CPULimit = g.CPULimit
+	}
+	if g.GPUCount > 0 {
+		gm.Spec.GPUCount = &g.GPUCount
+	}
+	if g.ContextSize > 0 {
+		gm.Spec.ContextSize = &g.ContextSize
+	}
+	if g.RuntimeParams != "" {
+		gm.Spec.RuntimeParams = g.RuntimeParams
+	}
+
+	dep := r.deploymentForModelServe(gm)
+	dep.Name = replicaGroupDeploymentName(m, g)
+	if configHash != "" {
+		dep.Spec.Template.Annotations[configHashAnnotation] = configHash
+	}
+	if awaitingActivation(m) {
+		useActivator(dep, gm)
+	}
+
+	// The group label keeps the group selectors apart from each other
+	groupLabels := map[string]string{replicaGroupLabel: g.Name}
+	for k, v := range labelsForModelServe(m.Name) {
+		groupLabels[k] = v
+	}
+	dep.Labels = groupLabels
+	dep.Spec.Selector = &metav1.LabelSelector{MatchLabels: groupLabels}
+	dep.Spec.Template.Labels[replicaGroupLabel] = g.Name
+
+	setTemplateHash(dep)
+	return dep
+}
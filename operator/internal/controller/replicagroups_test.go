@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+
+	modelv1alpha1 "github.com/example/model-operator/api/v1alpha1"
+)
+
+func TestReplicaGroupsShareService(t *testing.T) {
+	ms := newTestModelServe("mixed")
+	ms.Spec.MemoryLimit = 4096
+	ms.Spec.ReplicaGroups = []modelv1alpha1.ReplicaGroup{
+		{Name: "small", Replicas: 2, MemoryLimit: 2048},
+		{Name: "large", Replicas: 1, MemoryLimit: 16384, RuntimeParams: "--batch-size 512"},
+	}
+	r := newTestReconciler(t, ms)
+	reconcileUntilStable(t, r, "mixed")
+
+	svc := &corev1.Service{}
+	if err := r.Get(context.Background(), types.NamespacedName{Name: "mixed", Namespace: "default"}, svc); err != nil {
+		t.Fatal(err)
+	}
+	selector := labels.SelectorFromSet(svc.Spec.Selector)
+
+	for _, tt := range []struct {
+		name     string
+		replicas int32
+		memory   string
+	}{
+		{name: "mixed", replicas: 1, memory: "4096Mi"},
+		{name: "mixed-small", replicas: 2, memory: "2048Mi"},
+		{name: "mixed-large", replicas: 1, memory: "16384Mi"},
+	} {
+		dep := getDeployment(t, r, tt.name)
+		if *dep.Spec.Replicas != tt.replicas {
+			t.Fatalf("%s: expected %d replicas, got %d", tt.name, tt.replicas, *dep.Spec.Replicas)
+		}
+		limit := dep.Spec.Template.Spec.Containers[0].Resources.Limits[corev1.ResourceMemory]
+		if limit.Cmp(resource.MustParse(tt.memory)) != 0 {
+			t.Fatalf("%s: expected memory limit %s, got %s", tt.name, tt.memory, limit.String())
+		}
+		if !selector.Matches(labels.Set(dep.Spec.Template.Labels)) {
+			t.Fatalf("%s: expected the Service to select the pods, labels %v", tt.name, dep.Spec.Template.Labels)
+		}
+	}
+
+	large := getDeployment(t, r, "mixed-large")
+	args := large.Spec.Template.Spec.Containers[0].Args
+	if len(args) < 2 || args[len(args)-2] != "--batch-size" || args[len(args)-1] != "512" {
+		t.Fatalf("expected the group runtime params in the server args, got %v", args)
+	}
+
+	// Dropping a group removes its Deployment
+	ms = getModelServe(t, r, "mixed")
+	ms.Spec.ReplicaGroups = ms.Spec.ReplicaGroups[:1]
+	if err := r.Update(context.Background(), ms); err != nil {
+		t.Fatal(err)
+	}
+	reconcileUntilStable(t, r, "mixed")
+	err := r.Get(context.Background(), types.NamespacedName{Name: "mixed-large", Namespace: "default"}, large)
+	if !errors.IsNotFound(err) {
+		t.Fatalf("expected the removed group Deployment to be deleted, got %v", err)
+	}
+	getDeployment(t, r, "mixed-small")
+}
+
+func TestReplicaGroupLeavesForeignDeploymentAlone(t *testing.T) {
+	other := newTestModelServe("llama-small")
+	other.UID = "llama-small-uid"
+	ms := newTestModelServe("llama")
+	ms.UID = "llama-uid"
+	ms.Spec.ReplicaGroups = []modelv1alpha1.ReplicaGroup{{Name: "small", Replicas: 3}}
+	r := newTestReconciler(t, other, ms)
+	reconcileUntilStable(t, r, "llama-small")
+	reconcileUntilStable(t, r, "llama")
+
+	dep := getDeployment(t, r, "llama-small")
+	if _, ok := dep.Spec.Template.Labels[replicaGroupLabel]; ok {
+		t.Fatal("expected the replica group not to rewrite the template of another model")
+	}
+	if *dep.Spec.Replicas == 3 {
+		t.Fatal("expected the replica group not to scale the Deployment of another model")
+	}
+	if conflicts := getModelServe(t, r, "llama").Status.ReplicaGroupConflicts; len(conflicts) != 1 || conflicts[0] != "small" {
+		t.Fatalf("expected the conflict of group small in status, got %v", conflicts)
+	}
+}
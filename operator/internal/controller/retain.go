@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	modelv1alpha1 "github.com/example/model-operator/api/v1alpha1"
+)
+
+// retainedPodLabel marks a failed pod detached from its Deployment for debugging
+const retainedPodLabel = "model.example.com/retained-from"
+
+// reconcileRetainedPod keeps a failed model pod for debugging when
+// spec.retainOnFailure is set. It returns whether a pod is retained, in which
+// case the Deployment must stay scaled to zero.
+func (r *ModelServeReconciler) reconcileRetainedPod(ctx context.Context, m *modelv1alpha1.ModelServe) (bool, error) {
+	if m.Status.RetainedPod != "" {
+		pod := &corev1.Pod{}
+		err := r.Get(ctx, types.NamespacedName{Name: m.Status.RetainedPod, Namespace: m.Namespace}, pod)
+		if err == nil {
+			return true, nil
+		}
+		if !errors.IsNotFound(err) {
+			return false, err
+		}
+
+		// Debugging is done once the retained pod is deleted
+		m.Status.RetainedPod = ""
+		m.Status.Phase = "Pending"
+		m.Status.Message = "Retained pod removed, restarting model server"
+		return false, r.updateStatus(ctx, m)
+	}
+
+	if !m.Spec.RetainOnFailure {
+		return false, nil
+	}
+
+	podList := &corev1.PodList{}
+	if err := r.List(ctx, podList, client.InNamespace(m.Namespace), client.MatchingLabels(labelsForModelServe(m.Name))); err != nil {
+		return false, err
+	}
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		reason := podFailureReason(pod)
+		if reason == "" {
+			continue
+		}
+
+		// Without the app label the ReplicaSet releases the pod instead of
+		// replacing it, and the Service stops sending it traffic. The
+		// NetworkPolicy still selects it by model_serve_cr, and the owner
+		// reference removes it with the ModelServe.
+		patch := client.MergeFromWithOptions(pod.DeepCopy(), client.MergeFromWithOptimisticLock{})
+		delete(pod.Labels, "app")
+		pod.Labels[retainedPodLabel] = m.Name
+		if err := controllerutil.SetOwnerReference(m, pod, r.Scheme); err != nil {
+			return false, err
+		}
+		if err := r.Patch(ctx, pod, patch); err != nil {
+			return false, err
+		}
+		r.Recorder.Eventf(m, corev1.EventTypeWarning, "FailedPodRetained",
+			"Retained failed pod %s for debugging and scaled the model to zero: %s", pod.Name, reason)
+
+		m.Status.RetainedPod = pod.Name
+		m.Status.Phase = "Failed"
+		m.Status.Message = fmt.Sprintf("Failed (retained): pod %s kept for debugging, delete it to restart: %s", pod.Name, reason)
+		return true, r.updateStatus(ctx, m)
+	}
+	return false, nil
+}
+
+// podFailureReason describes why the pod keeps failing, or returns an empty
+// string while it is healthy or still starting
+func podFailureReason(pod *corev1.Pod) string {
+	if pod.Status.Phase == corev1.PodFailed {
+		return fmt.Sprintf("pod failed: %s", pod.Status.Reason)
+	}
+
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, cs := range statuses {
+		if cs.State.Waiting == nil || cs.State.Waiting.Reason != "CrashLoopBackOff" {
+			continue
+		}
+		if last := cs.LastTerminationState.Terminated; last != nil {
+			return fmt.Sprintf("container %s is crash looping (exit code %d)", cs.Name, last.ExitCode)
+		}
+		return fmt.Sprintf("container %s is crash looping", cs.Name)
+	}
+	return ""
+}
@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+
+	modelv1alpha1 "github.com/example/model-operator/api/v1alpha1"
+)
+
+func TestRetainFailedPod(t *testing.T) {
+	ms := newTestModelServe("retain")
+	ms.Spec.RetainOnFailure = true
+	ms.Spec.NetworkPolicy = &modelv1alpha1.NetworkPolicySpec{Enabled: true}
+	crashing := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "retain-abc", Namespace: "default", Labels: labelsForModelServe("retain")},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:                 "llama-server",
+				RestartCount:         4,
+				State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+				LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1}},
+			}},
+		},
+	}
+	r := newTestReconciler(t, ms)
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "retain", Namespace: "default"}
+	reconcileUntilStable(t, r, "retain")
+
+	// The model server starts crash looping
+	if err := r.Create(ctx, crashing); err != nil {
+		t.Fatal(err)
+	}
+	reconcileUntilStable(t, r, "retain")
+
+	if replicas := getDeployment(t, r, "retain").Spec.Replicas; replicas == nil || *replicas != 0 {
+		t.Fatalf("expected the Deployment scaled to zero, got %v", replicas)
+	}
+	pod := &corev1.Pod{}
+	if err := r.Get(ctx, types.NamespacedName{Name: "retain-abc", Namespace: "default"}, pod); err != nil {
+		t.Fatalf("expected the failed pod to be kept: %v", err)
+	}
+	if _, ok := pod.Labels["app"]; ok || pod.Labels[retainedPodLabel] != "retain" {
+		t.Fatalf("expected the pod detached from the Deployment, got labels %v", pod.Labels)
+	}
+	if len(pod.OwnerReferences) != 1 || pod.OwnerReferences[0].Kind != "ModelServe" || pod.OwnerReferences[0].Name != "retain" {
+		t.Fatalf("expected the retained pod owned by its ModelServe, got %+v", pod.OwnerReferences)
+	}
+	np := &networkingv1.NetworkPolicy{}
+	if err := r.Get(ctx, key, np); err != nil {
+		t.Fatal(err)
+	}
+	if selector, err := metav1.LabelSelectorAsSelector(&np.Spec.PodSelector); err != nil || !selector.Matches(labels.Set(pod.Labels)) {
+		t.Fatalf("expected the NetworkPolicy to keep isolating the retained pod, selector %v (%v), labels %v", np.Spec.PodSelector, err, pod.Labels)
+	}
+	if err := r.Get(ctx, key, ms); err != nil {
+		t.Fatal(err)
+	}
+	if ms.Status.Phase != "Failed" || ms.Status.RetainedPod != "retain-abc" || !strings.HasPrefix(ms.Status.Message, "Failed (retained)") {
+		t.Fatalf("expected a retained failure in status, got %+v", ms.Status)
+	}
+
+	// Deleting the retained pod resumes serving
+	if err := r.Delete(ctx, pod); err != nil {
+		t.Fatal(err)
+	}
+	reconcileUntilStable(t, r, "retain")
+
+	if replicas := getDeployment(t, r, "retain").Spec.Replicas; replicas == nil || *replicas != 1 {
+		t.Fatalf("expected the Deployment scaled back up, got %v", replicas)
+	}
+	if err := r.Get(ctx, key, ms); err != nil {
+		t.Fatal(err)
+	}
+	if ms.Status.RetainedPod != "" {
+		t.Fatalf("expected the retained pod to be cleared, got %q", ms.Status.RetainedPod)
+	}
+}
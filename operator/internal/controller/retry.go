@@ -0,0 +1,155 @@
+package controller
+
+import (
+	"context"
+	"net"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	modelv1alpha1 "github.com/example/model-operator/api/v1alpha1"
+)
+
+// Failure reasons of a failed download. Only an unreachable MinIO is retried;
+// any other failure needs a spec change.
+const (
+	failureMinIOUnavailable = "MinIOUnavailable"
+	failureDownload         = "DownloadFailed"
+)
+
+// Bounds of the backoff between retries of a transient failure
+const (
+	retryBaseDelay = 30 * time.Second
+	retryMaxDelay  = 10 * time.Minute
+)
+
+// MinIODialer opens and closes a connection to a MinIO endpoint
+type MinIODialer func(ctx context.Context, address string) error
+
+// dialMinIO connects to the MinIO endpoint of the model
+func dialMinIO(ctx context.Context, address string) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// minioReachable reports whether the MinIO endpoint of the model accepts connections
+func (r *ModelServeReconciler) minioReachable(ctx context.Context, m *modelv1alpha1.ModelServe) bool {
+	dial := r.MinIODialer
+	if dial == nil {
+		dial = dialMinIO
+	}
+	endpoint, _, _ := minioLocation(m)
+	return dial(ctx, endpoint) == nil
+}
+
+// retryDelay doubles the wait with every retry up to retryMaxDelay
+func retryDelay(retries int32) time.Duration {
+	delay := retryBaseDelay
+	for i := int32(0); i < retries && delay < retryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > retryMaxDelay {
+		delay = retryMaxDelay
+	}
+	return delay
+}
+
+// failDownload marks the ModelServe Failed for a failed download Job. When
+// MinIO cannot be reached the failure is recorded as transient and retried
+// after a backoff.
+func (r *ModelServeReconciler) failDownload(ctx context.Context, m *modelv1alpha1.ModelServe, err error) (ctrl.Result, error) {
+	m.Status.Phase = "Failed"
+	m.Status.Message = err.Error()
+	m.Status.FailureReason = failureDownload
+	m.Status.NextRetryAt = nil
+
+	result := ctrl.Result{}
+	if !r.minioReachable(ctx, m) {
+		delay := retryDelay(m.Status.DownloadRetries)
+		next := metav1.NewTime(time.Now().Add(delay))
+		m.Status.FailureReason = failureMinIOUnavailable
+		m.Status.NextRetryAt = &next
+		result.RequeueAfter = delay
+	}
+
+	if err := r.updateStatus(ctx, m); err != nil {
+		return ctrl.Result{}, err
+	}
+	return result, nil
+}
+
+// retryTransientFailure restarts the downloads of a model that failed while
+// MinIO was unavailable once its backoff passed and MinIO is reachable again.
+// It reports true while the model still waits for its next retry.
+func (r *ModelServeReconciler) retryTransientFailure(ctx context.Context, m *modelv1alpha1.ModelServe) (bool, ctrl.Result, error) {
+	if m.Status.Phase != "Failed" || m.Status.FailureReason != failureMinIOUnavailable {
+		return false, ctrl.Result{}, nil
+	}
+
+	if next := m.Status.NextRetryAt; next != nil {
+		if wait := time.Until(next.Time); wait > 0 {
+			return true, ctrl.Result{RequeueAfter: wait}, nil
+		}
+	}
+
+	m.Status.DownloadRetries++
+	if !r.minioReachable(ctx, m) {
+		delay := retryDelay(m.Status.DownloadRetries)
+		next := metav1.NewTime(time.Now().Add(delay))
+		m.Status.NextRetryAt = &next
+		if err := r.updateStatus(ctx, m); err != nil {
+			return true, ctrl.Result{}, err
+		}
+		return true, ctrl.Result{RequeueAfter: delay}, nil
+	}
+
+	// Failed Jobs do not run again, so replace them
+	for _, name := range []string{lazyDownloadJobName(m), preflightJobName(m), cacheResourceName(m)} {
+		job := &batchv1.Job{}
+		if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: m.Namespace}, job); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return true, ctrl.Result{}, err
+		}
+		if !metav1.IsControlledBy(job, m) || !jobFailed(job) {
+			continue
+		}
+		if err := r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !errors.IsNotFound(err) {
+			return true, ctrl.Result{}, err
+		}
+	}
+
+	r.Recorder.Eventf(m, corev1.EventTypeNormal, "RetryingDownload",
+		"MinIO is reachable again, retrying the download (attempt %d)", m.Status.DownloadRetries)
+	m.Status.Phase = "Pending"
+	m.Status.Message = "Retrying the download after MinIO recovered"
+	m.Status.FailureReason = ""
+	m.Status.NextRetryAt = nil
+	if err := r.updateStatus(ctx, m); err != nil {
+		return true, ctrl.Result{}, err
+	}
+	return false, ctrl.Result{}, nil
+}
+
+// jobFailed reports whether the Job gave up
+func jobFailed(job *batchv1.Job) bool {
+	for _, cond := range job.Status.Conditions {
+		if cond.Type == batchv1.JobFailed && cond.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// failPreflight marks the preflight Job of the named ModelServe failed
+func failPreflight(t *testing.T, r *ModelServeReconciler, name string) {
+	t.Helper()
+
+	job := &batchv1.Job{}
+	if err := r.Get(context.Background(), types.NamespacedName{Name: name + "-preflight", Namespace: "default"}, job); err != nil {
+		t.Fatal(err)
+	}
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}}
+	if err := r.Update(context.Background(), job); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTransientDownloadFailureIsRetried(t *testing.T) {
+	transient := newTestModelServe("transient")
+	transient.Spec.Preflight = true
+	permanent := newTestModelServe("permanent")
+	permanent.Spec.Preflight = true
+	permanent.Spec.MinIOEndpoint = "minio-up:9000"
+	r := newTestReconciler(t, transient, permanent)
+
+	minioUp := map[string]bool{"minio-up:9000": true}
+	r.MinIODialer = func(_ context.Context, address string) error {
+		if !minioUp[address] {
+			return fmt.Errorf("dial %s: connection refused", address)
+		}
+		return nil
+	}
+
+	for _, name := range []string{"transient", "permanent"} {
+		reconcileUntilStable(t, r, name)
+		failPreflight(t, r, name)
+	}
+	res, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "transient", Namespace: "default"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.RequeueAfter != retryBaseDelay {
+		t.Fatalf("expected a retry after %s, got %s", retryBaseDelay, res.RequeueAfter)
+	}
+	reconcileUntilStable(t, r, "permanent")
+
+	if status := getModelServe(t, r, "transient").Status; status.Phase != "Failed" || status.FailureReason != failureMinIOUnavailable {
+		t.Fatalf("expected a transient failure, got %q (%q)", status.Phase, status.FailureReason)
+	}
+	if status := getModelServe(t, r, "permanent").Status; status.Phase != "Failed" || status.FailureReason != failureDownload {
+		t.Fatalf("expected a permanent failure, got %q (%q)", status.Phase, status.FailureReason)
+	}
+
+	// MinIO recovers and the backoff passes
+	minioUp["minio:9000"] = true
+	ms := getModelServe(t, r, "transient")
+	past := metav1.NewTime(time.Now().Add(-time.Second))
+	ms.Status.NextRetryAt = &past
+	if err := r.Status().Update(context.Background(), ms); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"transient", "permanent"} {
+		reconcileUntilStable(t, r, name)
+	}
+
+	status := getModelServe(t, r, "transient").Status
+	if status.Phase != "Preflighting" || status.DownloadRetries != 1 || status.FailureReason != "" {
+		t.Fatalf("expected the transient failure to be retried, got %q retries %d (%q)", status.Phase, status.DownloadRetries, status.FailureReason)
+	}
+	job := &batchv1.Job{}
+	if err := r.Get(context.Background(), types.NamespacedName{Name: "transient-preflight", Namespace: "default"}, job); err != nil || jobFailed(job) {
+		t.Fatalf("expected a fresh preflight Job, got %+v (%v)", job.Status, err)
+	}
+
+	if status := getModelServe(t, r, "permanent").Status; status.Phase != "Failed" || status.DownloadRetries != 0 {
+		t.Fatalf("expected the permanent failure not to be retried, got %q retries %d", status.Phase, status.DownloadRetries)
+	}
+}
+
+func TestRetryDelayIsBounded(t *testing.T) {
+	if got := retryDelay(0); got != retryBaseDelay {
+		t.Fatalf("expected the first retry after %s, got %s", retryBaseDelay, got)
+	}
+	if got := retryDelay(2); got != 4*retryBaseDelay {
+		t.Fatalf("expected the delay to double, got %s", got)
+	}
+	if got := retryDelay(100); got != retryMaxDelay {
+		t.Fatalf("expected the delay to be capped at %s, got %s", retryMaxDelay, got)
+	}
+}
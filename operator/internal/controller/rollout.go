@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	modelv1alpha1 "github.com/example/model-operator/api/v1alpha1"
+)
+
+// rolloutDeferInterval is how often a deferred rollout checks for a free slot
+const rolloutDeferInterval = 15 * time.Second
+
+// maxConcurrentRollouts limits how many ModelServes of the cluster roll their
+// Deployment at once, configured as MAX_CONCURRENT_ROLLOUTS. Zero or an
+// invalid value leaves rollouts unlimited.
+func maxConcurrentRollouts() int {
+	limit, err := strconv.Atoi(os.Getenv("MAX_CONCURRENT_ROLLOUTS"))
+	if err != nil || limit < 0 {
+		return 0
+	}
+	return limit
+}
+
+// rolloutComplete reports whether every replica runs the latest pod template
+func rolloutComplete(dep *appsv1.Deployment) bool {
+	replicas := int32(1)
+	if dep.Spec.Replicas != nil {
+		replicas = *dep.Spec.Replicas
+	}
+	return dep.Status.ObservedGeneration >= dep.Generation &&
+		dep.Status.UpdatedReplicas == replicas &&
+		dep.Status.Replicas == replicas &&
+		dep.Status.AvailableReplicas >= replicas
+}
+
+// rolloutStuck reports whether the Deployment gave up rolling, which it does
+// not recover from on its own
+func rolloutStuck(dep *appsv1.Deployment) bool {
+	cond := deploymentCondition(dep, appsv1.DeploymentProgressing)
+	return cond != nil && cond.Status == corev1.ConditionFalse && cond.Reason == "ProgressDeadlineExceeded"
+}
+
+// rolloutsInProgress counts the other ModelServes of the cluster whose
+// Deployment is still rolling. The models are listed from the API server, as
+// the cache may not have caught up with a slot claimed by the reconcile just
+// before. Concurrent reconciles can still both claim the last slot, so the
+// limit is best effort.
+func (r *ModelServeReconciler) rolloutsInProgress(ctx context.Context, m *modelv1alpha1.ModelServe) (int, error) {
+	modelServes := &modelv1alpha1.ModelServeList{}
+	if err := r.apiReader().List(ctx, modelServes); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for i := range modelServes.Items {
+		other := &modelServes.Items[i]
+		if other.Status.RolloutInProgress && (other.Namespace != m.Namespace || other.Name != m.Name) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// startRollout claims a rollout slot for the ModelServe. It returns false when
+// MAX_CONCURRENT_ROLLOUTS other models are rolling, in which case the rollout
+// is deferred until one of them finishes.
+func (r *ModelServeReconciler) startRollout(ctx context.Context, m *modelv1alpha1.ModelServe) (bool, error) {
+	if limit := maxConcurrentRollouts(); limit > 0 && !m.Status.RolloutInProgress {
+		inProgress, err := r.rolloutsInProgress(ctx, m)
+		if err != nil {
+			return false, err
+		}
+		if inProgress >= limit {
+			message := fmt.Sprintf("Rollout deferred: %d of %d concurrent rollouts in progress", inProgress, limit)
+			if m.Status.Message != message {
+				r.Recorder.Event(m, corev1.EventTypeNormal, "RolloutDeferred", message)
+				m.Status.Message = message
+				return false, r.updateStatus(ctx, m)
+			}
+			return false, nil
+		}
+	}
+
+	m.Status.RolloutInProgress = true
+	m.Status.Message = "Rolling out updated pod template"
+	return true, r.updateStatus(ctx, m)
+}
+
+// finishRollout releases the rollout slot once the Deployment rolled out or
+// exceeded its progress deadline, so a model that never converges does not
+// hold the slot forever. A model failing for another reason releases it in
+// updateStatus.
+func (r *ModelServeReconciler) finishRollout(ctx context.Context, m *modelv1alpha1.ModelServe, dep *appsv1.Deployment) error {
+	if !m.Status.RolloutInProgress {
+		return nil
+	}
+
+	switch {
+	case rolloutComplete(dep):
+		m.Status.Message = "Rollout complete"
+	case rolloutStuck(dep):
+		m.Status.Message = "Rollout exceeded its progress deadline"
+	default:
+		return nil
+	}
+	m.Status.RolloutInProgress = false
+	return r.updateStatus(ctx, m)
+}
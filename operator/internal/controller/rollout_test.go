@@ -0,0 +1,146 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	modelv1alpha1 "github.com/example/model-operator/api/v1alpha1"
+)
+
+func TestConcurrentRolloutsAreThrottled(t *testing.T) {
+	t.Setenv("MAX_CONCURRENT_ROLLOUTS", "1")
+	names := []string{"roll-a", "roll-b", "roll-c"}
+
+	r := newTestReconciler(t, newTestModelServe("roll-a"), newTestModelServe("roll-b"), newTestModelServe("roll-c"))
+	for _, name := range names {
+		reconcileUntilStable(t, r, name)
+	}
+
+	// Fleet-wide image bump
+	const image = "ghcr.io/ggerganov/llama.cpp:server-b2000"
+	for _, name := range names {
+		ms := getModelServe(t, r, name)
+		ms.Spec.Image = image
+		if err := r.Update(context.Background(), ms); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rolled := func() []string {
+		var out []string
+		for _, name := range names {
+			if getDeployment(t, r, name).Spec.Template.Spec.Containers[0].Image == image {
+				out = append(out, name)
+			}
+		}
+		return out
+	}
+	reconcileAll := func() {
+		for _, name := range names {
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: "default"}}
+			if _, err := r.Reconcile(context.Background(), req); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	reconcileAll()
+	reconcileAll()
+	if got := rolled(); len(got) != 1 || got[0] != "roll-a" {
+		t.Fatalf("expected only roll-a to roll, got %v", got)
+	}
+	if ms := getModelServe(t, r, "roll-b"); ms.Status.RolloutInProgress || ms.Status.Message != "Rollout deferred: 1 of 1 concurrent rollouts in progress" {
+		t.Fatalf("expected roll-b to be deferred, got %+v", ms.Status)
+	}
+
+	// The Deployment controller finishes rolling roll-a, freeing its slot
+	dep := getDeployment(t, r, "roll-a")
+	dep.Status.Replicas = 1
+	dep.Status.UpdatedReplicas = 1
+	dep.Status.AvailableReplicas = 1
+	if err := r.Update(context.Background(), dep); err != nil {
+		t.Fatal(err)
+	}
+
+	reconcileAll()
+	if got := rolled(); len(got) != 2 || got[1] != "roll-b" {
+		t.Fatalf("expected roll-b to roll after roll-a finished, got %v", got)
+	}
+	if getModelServe(t, r, "roll-a").Status.RolloutInProgress {
+		t.Fatal("expected roll-a to release its rollout slot")
+	}
+}
+
+func TestBrokenRolloutsReleaseTheirSlot(t *testing.T) {
+	t.Setenv("MAX_CONCURRENT_ROLLOUTS", "1")
+	names := []string{"roll-a", "roll-b", "roll-c"}
+
+	r := newTestReconciler(t, newTestModelServe("roll-a"), newTestModelServe("roll-b"), newTestModelServe("roll-c"))
+	for _, name := range names {
+		reconcileUntilStable(t, r, name)
+	}
+
+	const image = "ghcr.io/ggerganov/llama.cpp:does-not-exist"
+	for _, name := range names {
+		ms := getModelServe(t, r, name)
+		ms.Spec.Image = image
+		if err := r.Update(context.Background(), ms); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reconcileAll := func() {
+		for _, name := range names {
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: "default"}}
+			if _, err := r.Reconcile(context.Background(), req); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	rolling := func(name string) bool {
+		return getDeployment(t, r, name).Spec.Template.Spec.Containers[0].Image == image
+	}
+
+	reconcileAll()
+	reconcileAll()
+	if !rolling("roll-a") || rolling("roll-b") {
+		t.Fatal("expected only roll-a to roll")
+	}
+
+	// roll-a never pulls its image and gives up
+	dep := getDeployment(t, r, "roll-a")
+	dep.Status.Conditions = []appsv1.DeploymentCondition{{
+		Type:   appsv1.DeploymentProgressing,
+		Status: corev1.ConditionFalse,
+		Reason: "ProgressDeadlineExceeded",
+	}}
+	if err := r.Update(context.Background(), dep); err != nil {
+		t.Fatal(err)
+	}
+	reconcileAll()
+	if getModelServe(t, r, "roll-a").Status.RolloutInProgress {
+		t.Fatal("expected the stuck roll-a to release its rollout slot")
+	}
+	if !rolling("roll-b") || rolling("roll-c") {
+		t.Fatal("expected roll-b to roll after roll-a got stuck")
+	}
+
+	// roll-b fails before its rollout finishes
+	ms := getModelServe(t, r, "roll-b")
+	ms.Spec.Storage = &modelv1alpha1.StorageSpec{Size: "lots"}
+	if err := r.Update(context.Background(), ms); err != nil {
+		t.Fatal(err)
+	}
+	reconcileAll()
+	reconcileAll()
+	if got := getModelServe(t, r, "roll-b"); got.Status.Phase != "Failed" || got.Status.RolloutInProgress {
+		t.Fatalf("expected the failed roll-b to release its rollout slot, got %+v", got.Status)
+	}
+	if !rolling("roll-c") {
+		t.Fatal("expected roll-c to roll after roll-b failed")
+	}
+}
@@ -0,0 +1,165 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	modelv1alpha1 "github.com/example/model-operator/api/v1alpha1"
+)
+
+// traefikGroupVersion is the API of the Traefik CRDs deployed with the gateway
+var traefikGroupVersion = schema.GroupVersion{Group: "traefik.containo.us", Version: "v1alpha1"}
+
+// routerPriority ranks the router IngressRoute above the model's own Ingress
+// so requests on the model path fan out to the backends
+const routerPriority = 100
+
+// routerName names the TraefikService and IngressRoute of a router model
+func routerName(m *modelv1alpha1.ModelServe) string {
+	return m.Name + "-router"
+}
+
+// reconcileRouter creates the weighted TraefikService over spec.backends and
+// the IngressRoute sending the model path to it, or removes both once the
+// model no longer has backends. Status.RoutedBackends records the backends
+// receiving traffic.
+func (r *ModelServeReconciler) reconcileRouter(ctx context.Context, m *modelv1alpha1.ModelServe) error {
+	services, routed, err := r.routerServices(ctx, m)
+	if err != nil {
+		return err
+	}
+	if len(services) == 0 {
+		if len(m.Status.RoutedBackends) == 0 {
+			return nil
+		}
+		if err := r.deleteRouter(ctx, m); err != nil {
+			return err
+		}
+		m.Status.RoutedBackends = nil
+		return r.updateStatus(ctx, m)
+	}
+
+	weighted := newTraefikObject("TraefikService", m)
+	weighted.Object["spec"] = map[string]interface{}{
+		"weighted": map[string]interface{}{"services": services},
+	}
+
+	// Same middleware chain as the model's Ingress
+	route := newTraefikObject("IngressRoute", m)
+	route.Object["spec"] = map[string]interface{}{
+		"entryPoints": []interface{}{"web"},
+		"routes": []interface{}{map[string]interface{}{
+			"match":    fmt.Sprintf("PathPrefix(`%s`)", m.RoutePath()),
+			"kind":     "Rule",
+			"priority": int64(routerPriority),
+			"services": []interface{}{map[string]interface{}{
+				"name": routerName(m),
+				"kind": "TraefikService",
+			}},
+			"middlewares": routerMiddlewares(m),
+		}},
+	}
+
+	for _, obj := range []*unstructured.Unstructured{weighted, route} {
+		if err := ctrl.SetControllerReference(m, obj, r.Scheme); err != nil {
+			return err
+		}
+		if err := r.applyTraefikObject(ctx, obj); err != nil {
+			return fmt.Errorf("failed to apply %s %s: %w", obj.GetKind(), obj.GetName(), err)
+		}
+	}
+
+	if reflect.DeepEqual(m.Status.RoutedBackends, routed) {
+		return nil
+	}
+	m.Status.RoutedBackends = routed
+	return r.updateStatus(ctx, m)
+}
+
+// routerServices returns the weighted Traefik services of the backends that
+// exist. Missing backends are skipped so the others keep serving.
+func (r *ModelServeReconciler) routerServices(ctx context.Context, m *modelv1alpha1.ModelServe) ([]interface{}, []string, error) {
+	var services []interface{}
+	var routed []string
+	for _, b := range m.Spec.Backends {
+		backend := &modelv1alpha1.ModelServe{}
+		err := r.Get(ctx, types.NamespacedName{Name: b.Name, Namespace: m.Namespace}, backend)
+		if errors.IsNotFound(err) {
+			r.Recorder.Eventf(m, corev1.EventTypeWarning, "BackendNotFound",
+				"Backend ModelServe %s does not exist, routing to the remaining backends", b.Name)
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// The Service of a ModelServe shares its name
+		services = append(services, map[string]interface{}{
+			"name":   b.Name,
+			"port":   int64(80),
+			"weight": int64(b.Weight),
+		})
+		routed = append(routed, b.Name)
+	}
+	return services, routed, nil
+}
+
+// newTraefikObject returns an empty Traefik object of the kind for the router
+func newTraefikObject(kind string, m *modelv1alpha1.ModelServe) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(traefikGroupVersion.WithKind(kind))
+	obj.SetName(routerName(m))
+	obj.SetNamespace(m.Namespace)
+	obj.SetLabels(labelsForModelServe(m.Name))
+	return obj
+}
+
+// applyTraefikObject creates the object or brings the spec of the existing one in line
+func (r *ModelServeReconciler) applyTraefikObject(ctx context.Context, obj *unstructured.Unstructured) error {
+	found := &unstructured.Unstructured{}
+	found.SetGroupVersionKind(obj.GroupVersionKind())
+	err := r.Get(ctx, types.NamespacedName{Name: obj.GetName(), Namespace: obj.GetNamespace()}, found)
+	if errors.IsNotFound(err) {
+		return r.Create(ctx, obj)
+	}
+	if err != nil {
+		return err
+	}
+
+	if reflect.DeepEqual(found.Object["spec"], obj.Object["spec"]) {
+		return nil
+	}
+	found.Object["spec"] = obj.Object["spec"]
+	return r.Update(ctx, found)
+}
+
+// deleteRouter removes the router objects. Clusters without the Traefik
+// CRDs have nothing to remove.
+func (r *ModelServeReconciler) deleteRouter(ctx context.Context, m *modelv1alpha1.ModelServe) error {
+	for _, kind := range []string{"IngressRoute", "TraefikService"} {
+		err := r.Delete(ctx, newTraefikObject(kind, m))
+		if err != nil && !errors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// routerMiddlewares returns the middlewares of the router IngressRoute in the
+// order of middlewareChain
+func routerMiddlewares(m *modelv1alpha1.ModelServe) []interface{} {
+	var middlewares []interface{}
+	if !m.Spec.Public {
+		middlewares = append(middlewares, map[string]interface{}{"name": "jwt-auth"})
+	}
+	return append(middlewares, map[string]interface{}{"name": m.Name + "-stripprefix"})
+}
@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	modelv1alpha1 "github.com/example/model-operator/api/v1alpha1"
+)
+
+// getTraefikObject fetches the Traefik object of the kind and name
+func getTraefikObject(t *testing.T, r *ModelServeReconciler, kind, name string) (*unstructured.Unstructured, error) {
+	t.Helper()
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(traefikGroupVersion.WithKind(kind))
+	err := r.Get(context.Background(), types.NamespacedName{Name: name, Namespace: "default"}, obj)
+	return obj, err
+}
+
+func TestRouterWiresWeightedBackends(t *testing.T) {
+	router := newTestModelServe("router")
+	router.Spec.Backends = []modelv1alpha1.ModelServeRef{
+		{Name: "llama-small", Weight: 3},
+		{Name: "llama-large", Weight: 1},
+		{Name: "missing", Weight: 5},
+	}
+	r := newTestReconciler(t, router, newTestModelServe("llama-small"), newTestModelServe("llama-large"))
+	reconcileUntilStable(t, r, "router")
+
+	weighted, err := getTraefikObject(t, r, "TraefikService", "router-router")
+	if err != nil {
+		t.Fatalf("get TraefikService: %v", err)
+	}
+	services, _, _ := unstructured.NestedSlice(weighted.Object, "spec", "weighted", "services")
+	if len(services) != 2 {
+		t.Fatalf("expected the two existing backends, got %v", services)
+	}
+	want := map[string]int64{"llama-small": 3, "llama-large": 1}
+	for _, s := range services {
+		svc := s.(map[string]interface{})
+		if want[svc["name"].(string)] != svc["weight"].(int64) || svc["port"].(int64) != 80 {
+			t.Fatalf("unexpected weighted service %v", svc)
+		}
+	}
+
+	route, err := getTraefikObject(t, r, "IngressRoute", "router-router")
+	if err != nil {
+		t.Fatalf("get IngressRoute: %v", err)
+	}
+	routes, _, _ := unstructured.NestedSlice(route.Object, "spec", "routes")
+	rule := routes[0].(map[string]interface{})
+	target := rule["services"].([]interface{})[0].(map[string]interface{})
+	if rule["match"] != "PathPrefix(`/router`)" || target["name"] != "router-router" || target["kind"] != "TraefikService" {
+		t.Fatalf("expected the model path to route to the TraefikService, got %v", rule)
+	}
+	if owners := route.GetOwnerReferences(); len(owners) != 1 || owners[0].Name != "router" {
+		t.Fatalf("expected IngressRoute to be owned by the router, got %v", owners)
+	}
+
+	var warned bool
+	for _, e := range drainEvents(r) {
+		warned = warned || strings.HasPrefix(e, "Warning BackendNotFound") && strings.Contains(e, "missing")
+	}
+	if !warned {
+		t.Fatal("expected a BackendNotFound warning for the missing backend")
+	}
+	if got := getModelServe(t, r, "router").Status.RoutedBackends; len(got) != 2 {
+		t.Fatalf("expected two routed backends in status, got %v", got)
+	}
+
+	// Dropping the backends removes the router
+	ms := getModelServe(t, r, "router")
+	ms.Spec.Backends = nil
+	if err := r.Update(context.Background(), ms); err != nil {
+		t.Fatal(err)
+	}
+	reconcileUntilStable(t, r, "router")
+	if _, err := getTraefikObject(t, r, "IngressRoute", "router-router"); !errors.IsNotFound(err) {
+		t.Fatalf("expected IngressRoute to be removed, got %v", err)
+	}
+	if got := getModelServe(t, r, "router").Status.RoutedBackends; len(got) != 0 {
+		t.Fatalf("expected routed backends to be cleared, got %v", got)
+	}
+}
@@ -0,0 +1,120 @@
+package controller
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	modelv1alpha1 "github.com/example/model-operator/api/v1alpha1"
+)
+
+// nonRootUID runs the containers of restricted pods. All containers share it,
+// so the server can read the model the download container wrote.
+const nonRootUID = int64(1000)
+
+// restrictedSecurity reports whether the pods follow the restricted Pod
+// Security Standard
+func restrictedSecurity(m *modelv1alpha1.ModelServe) bool {
+	return m.Spec.SecurityProfile != modelv1alpha1.SecurityProfileNone
+}
+
+// applySecurityProfile sets the security context required by the restricted
+// Pod Security Standard on the pod and all its containers
+func applySecurityProfile(podSpec *corev1.PodSpec, m *modelv1alpha1.ModelServe) {
+	if !restrictedSecurity(m) {
+		if m.Spec.FSGroup != nil {
+			podSpec.SecurityContext = &corev1.PodSecurityContext{}
+			setFSGroup(podSpec.SecurityContext, *m.Spec.FSGroup)
+		}
+		return
+	}
+
+	runAsNonRoot := true
+	uid := nonRootUID
+	podSpec.SecurityContext = &corev1.PodSecurityContext{
+		RunAsNonRoot:   &runAsNonRoot,
+		RunAsUser:      &uid,
+		RunAsGroup:     &uid,
+		SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+	}
+	fsGroup := nonRootUID
+	if m.Spec.FSGroup != nil {
+		fsGroup = *m.Spec.FSGroup
+	}
+	setFSGroup(podSpec.SecurityContext, fsGroup)
+
+	for _, containers := range [][]corev1.Container{podSpec.InitContainers, podSpec.Containers} {
+		for i := range containers {
+			c := &containers[i]
+			// DCGM cannot read the GPUs under the restricted profile
+			if c.Name == dcgmContainerName {
+				continue
+			}
+			allowPrivilegeEscalation := false
+			c.SecurityContext = &corev1.SecurityContext{
+				AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+				Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+			}
+
+			// The UID has no home directory in the images, but mc keeps its
+			// configuration and pip its user installs there
+			if !hasEnv(c, "HOME") {
+				c.Env = append(c.Env, corev1.EnvVar{Name: "HOME", Value: "/tmp"})
+			}
+		}
+	}
+}
+
+// scratchDirs are the directories the server writes to at runtime, mounted
+// from emptyDir volumes under a read-only root filesystem
+var scratchDirs = []struct{ volume, path string }{
+	{volume: "scratch-tmp", path: "/tmp"},
+	{volume: "scratch-cache", path: "/var/cache/llama.cpp"},
+}
+
+// applyReadOnlyRoot makes the root filesystem of the server container
+// read-only. The scratch directories stay writable, and the cache variables
+// point llama.cpp at the writable cache directory instead of $HOME/.cache.
+func applyReadOnlyRoot(podSpec *corev1.PodSpec, m *modelv1alpha1.ModelServe) {
+	if !m.Spec.ReadOnlyRootFilesystem {
+		return
+	}
+
+	c := &podSpec.Containers[0]
+	if c.SecurityContext == nil {
+		c.SecurityContext = &corev1.SecurityContext{}
+	}
+	readOnly := true
+	c.SecurityContext.ReadOnlyRootFilesystem = &readOnly
+
+	for _, dir := range scratchDirs {
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name:         dir.volume,
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		})
+		c.VolumeMounts = append(c.VolumeMounts, corev1.VolumeMount{Name: dir.volume, MountPath: dir.path})
+	}
+	for _, name := range []string{"LLAMA_CACHE", "XDG_CACHE_HOME"} {
+		if !hasEnv(c, name) {
+			c.Env = append(c.Env, corev1.EnvVar{Name: name, Value: scratchDirs[1].path})
+		}
+	}
+}
+
+// setFSGroup makes the volumes of the pod group owned by fsGroup. Every
+// container of the pod gets the group, so whatever the download container
+// writes stays writable for the server. Ownership is only changed when the
+// volume root does not match, which keeps restarts on a large model cheap.
+func setFSGroup(sc *corev1.PodSecurityContext, fsGroup int64) {
+	policy := corev1.FSGroupChangeOnRootMismatch
+	sc.FSGroup = &fsGroup
+	sc.FSGroupChangePolicy = &policy
+}
+
+// hasEnv reports whether the container sets the environment variable
+func hasEnv(c *corev1.Container, name string) bool {
+	for _, env := range c.Env {
+		if env.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,214 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	modelv1alpha1 "github.com/example/model-operator/api/v1alpha1"
+)
+
+// restrictedViolations lists where the pod breaks the restricted Pod Security
+// Standard. It covers the checks the generated pods could fail.
+func restrictedViolations(podSpec *corev1.PodSpec) []string {
+	var violations []string
+	psc := podSpec.SecurityContext
+	if psc == nil {
+		psc = &corev1.PodSecurityContext{}
+	}
+	if podSpec.HostNetwork || podSpec.HostPID || podSpec.HostIPC {
+		violations = append(violations, "host namespaces")
+	}
+	if psc.RunAsUser != nil && *psc.RunAsUser == 0 {
+		violations = append(violations, "pod runAsUser=0")
+	}
+	for _, v := range podSpec.Volumes {
+		if v.HostPath != nil {
+			violations = append(violations, "hostPath volume "+v.Name)
+		}
+	}
+
+	containers := append(append([]corev1.Container{}, podSpec.InitContainers...), podSpec.Containers...)
+	for _, c := range containers {
+		sc := c.SecurityContext
+		if sc == nil {
+			sc = &corev1.SecurityContext{}
+		}
+		if sc.Privileged != nil && *sc.Privileged {
+			violations = append(violations, c.Name+": privileged")
+		}
+		if sc.AllowPrivilegeEscalation == nil || *sc.AllowPrivilegeEscalation {
+			violations = append(violations, c.Name+": allowPrivilegeEscalation != false")
+		}
+		if !(sc.RunAsNonRoot != nil && *sc.RunAsNonRoot) && !(sc.RunAsNonRoot == nil && psc.RunAsNonRoot != nil && *psc.RunAsNonRoot) {
+			violations = append(violations, c.Name+": runAsNonRoot != true")
+		}
+		if sc.RunAsUser != nil && *sc.RunAsUser == 0 {
+			violations = append(violations, c.Name+": runAsUser=0")
+		}
+		seccomp := sc.SeccompProfile
+		if seccomp == nil {
+			seccomp = psc.SeccompProfile
+		}
+		if seccomp == nil || (seccomp.Type != corev1.SeccompProfileTypeRuntimeDefault && seccomp.Type != corev1.SeccompProfileTypeLocalhost) {
+			violations = append(violations, c.Name+": seccompProfile not RuntimeDefault or Localhost")
+		}
+		dropsAll := false
+		if sc.Capabilities != nil {
+			for _, capability := range sc.Capabilities.Drop {
+				dropsAll = dropsAll || capability == "ALL"
+			}
+			for _, capability := range sc.Capabilities.Add {
+				if capability != "NET_BIND_SERVICE" {
+					violations = append(violations, c.Name+": adds capability "+string(capability))
+				}
+			}
+		}
+		if !dropsAll {
+			violations = append(violations, c.Name+": capabilities not dropping ALL")
+		}
+		for _, p := range c.Ports {
+			if p.HostPort != 0 {
+				violations = append(violations, c.Name+": hostPort")
+			}
+		}
+	}
+	return violations
+}
+
+func TestPodsMeetRestrictedPodSecurity(t *testing.T) {
+	ms := newTestModelServe("restricted")
+	ms.Spec.StartupScript = "echo ready"
+	ms.Spec.Storage = &modelv1alpha1.StorageSpec{Size: "20Gi"}
+	r := newTestReconciler(t, ms)
+	reconcileUntilStable(t, r, "restricted")
+
+	podSpec := &getDeployment(t, r, "restricted").Spec.Template.Spec
+	if v := restrictedViolations(podSpec); len(v) != 0 {
+		t.Fatalf("model pod violates the restricted Pod Security Standard: %v", v)
+	}
+	// The download writes the model the server reads, so they share the UID
+	for _, c := range append(podSpec.InitContainers, podSpec.Containers...) {
+		if c.SecurityContext.RunAsUser != nil {
+			t.Fatalf("expected container %s to inherit the pod UID", c.Name)
+		}
+		if !hasEnv(&c, "HOME") {
+			t.Fatalf("expected container %s to get a writable HOME", c.Name)
+		}
+	}
+
+	job := downloadJob(ms, "restricted-download", nil, "model-cache", "model.gguf")
+	if v := restrictedViolations(&job.Spec.Template.Spec); len(v) != 0 {
+		t.Fatalf("download job violates the restricted Pod Security Standard: %v", v)
+	}
+}
+
+func TestSecurityProfileNoneOptsOut(t *testing.T) {
+	ms := newTestModelServe("unconfined")
+	ms.Spec.SecurityProfile = modelv1alpha1.SecurityProfileNone
+	r := newTestReconciler(t, ms)
+	reconcileUntilStable(t, r, "unconfined")
+
+	podSpec := getDeployment(t, r, "unconfined").Spec.Template.Spec
+	if podSpec.SecurityContext != nil || podSpec.Containers[0].SecurityContext != nil {
+		t.Fatalf("expected no security context with securityProfile None, got %+v", podSpec.SecurityContext)
+	}
+}
+
+func TestDefaultedPodSecurityContextDoesNotRoll(t *testing.T) {
+	ms := newTestModelServe("defaulted")
+	ms.Spec.SecurityProfile = modelv1alpha1.SecurityProfileNone
+	r := newTestReconciler(t, ms)
+	reconcileUntilStable(t, r, "defaulted")
+
+	// The API server stores a missing pod security context as {}
+	dep := getDeployment(t, r, "defaulted")
+	dep.Spec.Template.Spec.SecurityContext = &corev1.PodSecurityContext{}
+	if err := r.Update(context.Background(), dep); err != nil {
+		t.Fatal(err)
+	}
+	reconcileUntilStable(t, r, "defaulted")
+
+	if getModelServe(t, r, "defaulted").Status.RolloutInProgress {
+		t.Fatal("expected the defaulted security context not to start a rollout")
+	}
+	if sc := getDeployment(t, r, "defaulted").Spec.Template.Spec.SecurityContext; sc == nil {
+		t.Fatal("expected the pod template to be left alone")
+	}
+}
+
+func TestFSGroupSharedByPodContainers(t *testing.T) {
+	ms := newTestModelServe("fsgroup")
+	ms.Spec.Storage = &modelv1alpha1.StorageSpec{Size: "20Gi"}
+	r := newTestReconciler(t, ms)
+	reconcileUntilStable(t, r, "fsgroup")
+
+	// Restricted pods default to the non-root UID as volume group
+	sc := getDeployment(t, r, "fsgroup").Spec.Template.Spec.SecurityContext
+	if sc == nil || sc.FSGroup == nil || *sc.FSGroup != nonRootUID {
+		t.Fatalf("expected fsGroup %d on the pod, got %+v", nonRootUID, sc)
+	}
+
+	explicit := int64(2000)
+	ms = getModelServe(t, r, "fsgroup")
+	ms.Spec.FSGroup = &explicit
+	ms.Spec.SecurityProfile = modelv1alpha1.SecurityProfileNone
+	if err := r.Update(context.Background(), ms); err != nil {
+		t.Fatal(err)
+	}
+	reconcileUntilStable(t, r, "fsgroup")
+
+	podSpec := getDeployment(t, r, "fsgroup").Spec.Template.Spec
+	if podSpec.SecurityContext == nil || podSpec.SecurityContext.FSGroup == nil || *podSpec.SecurityContext.FSGroup != explicit {
+		t.Fatalf("expected fsGroup %d on the pod, got %+v", explicit, podSpec.SecurityContext)
+	}
+	for _, c := range append(podSpec.InitContainers, podSpec.Containers...) {
+		if c.SecurityContext != nil {
+			t.Fatalf("expected container %s to use the pod security context", c.Name)
+		}
+	}
+}
+
+func TestReadOnlyRootKeepsScratchMounts(t *testing.T) {
+	ms := newTestModelServe("readonly")
+	r := newTestReconciler(t, ms)
+	reconcileUntilStable(t, r, "readonly")
+
+	// Turning the flag on rolls the server onto a read-only root
+	ms = getModelServe(t, r, "readonly")
+	ms.Spec.ReadOnlyRootFilesystem = true
+	if err := r.Update(context.Background(), ms); err != nil {
+		t.Fatal(err)
+	}
+	reconcileUntilStable(t, r, "readonly")
+
+	podSpec := getDeployment(t, r, "readonly").Spec.Template.Spec
+	server := podSpec.Containers[0]
+	if server.SecurityContext == nil || server.SecurityContext.ReadOnlyRootFilesystem == nil || !*server.SecurityContext.ReadOnlyRootFilesystem {
+		t.Fatalf("expected a read-only root filesystem on the server, got %+v", server.SecurityContext)
+	}
+	if server.SecurityContext.Capabilities == nil {
+		t.Fatal("expected the restricted security context to be kept")
+	}
+	for _, dir := range scratchDirs {
+		mounted := false
+		for _, vm := range server.VolumeMounts {
+			if vm.Name == dir.volume && vm.MountPath == dir.path && !vm.ReadOnly {
+				mounted = true
+			}
+		}
+		emptyDir := false
+		for _, v := range podSpec.Volumes {
+			if v.Name == dir.volume && v.EmptyDir != nil {
+				emptyDir = true
+			}
+		}
+		if !mounted || !emptyDir {
+			t.Fatalf("expected a writable emptyDir at %s, got mounts %v", dir.path, server.VolumeMounts)
+		}
+	}
+	if !hasEnv(&server, "LLAMA_CACHE") {
+		t.Fatal("expected LLAMA_CACHE to point at the writable cache directory")
+	}
+}
@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	modelv1alpha1 "github.com/example/model-operator/api/v1alpha1"
+)
+
+// persistentStorage reports whether the model is kept on a claim owned by the ModelServe
+func persistentStorage(m *modelv1alpha1.ModelServe) bool {
+	return m.Spec.Storage != nil && m.Spec.Storage.Size != ""
+}
+
+// modelClaimName is the name of the claim created for spec.storage.size
+func modelClaimName(m *modelv1alpha1.ModelServe) string {
+	return m.Name + "-model"
+}
+
+// modelVolumeSource returns the volume backing /models
+func modelVolumeSource(m *modelv1alpha1.ModelServe) corev1.VolumeSource {
+	if persistentStorage(m) {
+		return corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: modelClaimName(m)},
+		}
+	}
+
+	sizeGi := int64(10) // 10GB default
+	if m.Spec.Storage != nil && m.Spec.Storage.EphemeralSizeGi > 0 {
+		sizeGi = int64(m.Spec.Storage.EphemeralSizeGi)
+	}
+	return corev1.VolumeSource{
+		EmptyDir: &corev1.EmptyDirVolumeSource{
+			SizeLimit: resource.NewQuantity(sizeGi*1024*1024*1024, resource.BinarySI),
+		},
+	}
+}
+
+// failureInvalidStorage marks a model whose storage.size is not a quantity
+const failureInvalidStorage = "InvalidStorageSize"
+
+// checkStorageSize fails the model while storage.size, which a ModelServeClass
+// may also set, cannot be parsed as a quantity. Without the webhook nothing
+// else rejects it. A model that failed this way resumes once the size is
+// fixed. It reports true while the size is invalid.
+func (r *ModelServeReconciler) checkStorageSize(ctx context.Context, m *modelv1alpha1.ModelServe) (bool, error) {
+	problem := ""
+	if persistentStorage(m) {
+		if _, err := resource.ParseQuantity(m.Spec.Storage.Size); err != nil {
+			problem = fmt.Sprintf("Invalid storage.size %q: %v", m.Spec.Storage.Size, err)
+		}
+	}
+
+	if problem == "" {
+		if m.Status.FailureReason != failureInvalidStorage {
+			return false, nil
+		}
+		m.Status.Phase = "Pending"
+		m.Status.Message = "Storage size is valid"
+		m.Status.FailureReason = ""
+		return false, r.updateStatus(ctx, m)
+	}
+
+	if m.Status.Phase != "Failed" || m.Status.Message != problem {
+		r.Recorder.Event(m, corev1.EventTypeWarning, failureInvalidStorage, problem)
+		m.Status.Phase = "Failed"
+		m.Status.Message = problem
+		m.Status.FailureReason = failureInvalidStorage
+		if err := r.updateStatus(ctx, m); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+// reconcileModelClaim creates the model claim when persistent storage is requested.
+// The claim is never resized or deleted here; it is garbage collected with the ModelServe.
+func (r *ModelServeReconciler) reconcileModelClaim(ctx context.Context, m *modelv1alpha1.ModelServe) error {
+	if !persistentStorage(m) {
+		return nil
+	}
+
+	found := &corev1.PersistentVolumeClaim{}
+	err := r.Get(ctx, types.NamespacedName{Name: modelClaimName(m), Namespace: m.Namespace}, found)
+	if err == nil || !errors.IsNotFound(err) {
+		return err
+	}
+
+	size, err := resource.ParseQuantity(m.Spec.Storage.Size)
+	if err != nil {
+		return fmt.Errorf("invalid storage.size %q: %v", m.Spec.Storage.Size, err)
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      modelClaimName(m),
+			Namespace: m.Namespace,
+			Labels:    labelsForModelServe(m.Name),
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			StorageClassName: m.Spec.Storage.StorageClassName,
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: size,
+				},
+			},
+		},
+	}
+	if err := ctrl.SetControllerReference(m, pvc, r.Scheme); err != nil {
+		return err
+	}
+	return r.Create(ctx, pvc)
+}
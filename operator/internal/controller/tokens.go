@@ -0,0 +1,146 @@
+package controller
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	modelv1alpha1 "github.com/example/model-operator/api/v1alpha1"
+)
+
+// Token counters exported by the llama.cpp server on its metrics endpoint.
+// The monitor sidecar reads the same names through its environment.
+const (
+	promptTokensMetric    = "llamacpp:prompt_tokens_total"
+	generatedTokensMetric = "llamacpp:tokens_predicted_total"
+)
+
+// tokenPollInterval is how often the token totals of a running model are refreshed
+const tokenPollInterval = time.Minute
+
+// TokenCounts are the tokens processed by a model server since it started
+type TokenCounts struct {
+	Prompt    int64
+	Generated int64
+}
+
+// TokenFetcher returns the token counters reported by a model pod
+type TokenFetcher func(ctx context.Context, pod *corev1.Pod, path string) (TokenCounts, error)
+
+// serverMetricsEndpoint returns the path of the server's own metrics
+func serverMetricsEndpoint(m *modelv1alpha1.ModelServe) string {
+	if m.Spec.Monitoring != nil && m.Spec.Monitoring.MetricsEndpoint != "" {
+		return m.Spec.Monitoring.MetricsEndpoint
+	}
+	return "/metrics"
+}
+
+// tokenCounts sums the token counters of the ready model pods. It reports
+// false when no pod answered.
+func (r *ModelServeReconciler) tokenCounts(ctx context.Context, m *modelv1alpha1.ModelServe) (TokenCounts, bool) {
+	fetch := r.TokenFetcher
+	if fetch == nil {
+		fetch = fetchTokenCounts
+	}
+
+	podList := &corev1.PodList{}
+	if err := r.List(ctx, podList, client.InNamespace(m.Namespace), client.MatchingLabels(labelsForModelServe(m.Name))); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list pods for token counts")
+		return TokenCounts{}, false
+	}
+
+	var total TokenCounts
+	found := false
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" || !podReady(pod) {
+			continue
+		}
+		counts, err := fetch(ctx, pod, serverMetricsEndpoint(m))
+		if err != nil {
+			log.FromContext(ctx).V(1).Info("Token counts not available", "Pod", pod.Name, "error", err.Error())
+			continue
+		}
+		total.Prompt += counts.Prompt
+		total.Generated += counts.Generated
+		found = true
+	}
+	return total, found
+}
+
+// fetchTokenCounts scrapes the metrics endpoint of the pod directly
+func fetchTokenCounts(ctx context.Context, pod *corev1.Pod, path string) (TokenCounts, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://%s:8080%s", pod.Status.PodIP, path), nil)
+	if err != nil {
+		return TokenCounts{}, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return TokenCounts{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return TokenCounts{}, fmt.Errorf("metrics endpoint returned %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return TokenCounts{}, err
+	}
+	return parseTokenCounts(body)
+}
+
+// parseTokenCounts reads the token counters from Prometheus text format
+func parseTokenCounts(body []byte) (TokenCounts, error) {
+	var counts TokenCounts
+	found := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		// Labels, if any, are part of the metric name field
+		name := fields[0]
+		if i := strings.IndexByte(name, '{'); i >= 0 {
+			name = name[:i]
+		}
+		if name != promptTokensMetric && name != generatedTokensMetric {
+			continue
+		}
+
+		v, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return TokenCounts{}, fmt.Errorf("invalid value for %s: %v", name, err)
+		}
+		if name == promptTokensMetric {
+			counts.Prompt += int64(v)
+		} else {
+			counts.Generated += int64(v)
+		}
+		found = true
+	}
+	if err := scanner.Err(); err != nil {
+		return TokenCounts{}, err
+	}
+
+	if !found {
+		return TokenCounts{}, fmt.Errorf("no token metrics found")
+	}
+	return counts, nil
+}
@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	modelv1alpha1 "github.com/example/model-operator/api/v1alpha1"
+)
+
+func TestTokenTotalsSurfacedInStatus(t *testing.T) {
+	ms := newTestModelServe("tokens")
+	ms.Spec.Monitoring = &modelv1alpha1.MonitoringSpec{MetricsEndpoint: "/stats/metrics"}
+	readyPod := func(name string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labelsForModelServe("tokens")},
+			Status: corev1.PodStatus{
+				Phase:      corev1.PodRunning,
+				PodIP:      "10.0.0.7",
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+			},
+		}
+	}
+	r := newTestReconciler(t, ms, readyPod("tokens-a"), readyPod("tokens-b"))
+
+	counts := map[string]TokenCounts{"tokens-a": {Prompt: 100, Generated: 40}, "tokens-b": {Prompt: 20, Generated: 2}}
+	r.TokenFetcher = func(_ context.Context, p *corev1.Pod, path string) (TokenCounts, error) {
+		if path != "/stats/metrics" {
+			t.Fatalf("unexpected metrics path %s", path)
+		}
+		return counts[p.Name], nil
+	}
+
+	reconcileUntilStable(t, r, "tokens")
+
+	var env map[string]string
+	for _, c := range getDeployment(t, r, "tokens").Spec.Template.Spec.Containers {
+		if c.Name == "monitor-sidecar" {
+			env = map[string]string{}
+			for _, e := range c.Env {
+				env[e.Name] = e.Value
+			}
+		}
+	}
+	if env["SERVER_METRICS_ENDPOINT"] != "/stats/metrics" || env["PROMPT_TOKENS_METRIC"] != promptTokensMetric || env["GENERATED_TOKENS_METRIC"] != generatedTokensMetric {
+		t.Fatalf("expected the sidecar to get the metrics endpoint and token metric names, got %v", env)
+	}
+
+	dep := getDeployment(t, r, "tokens")
+	dep.Status.AvailableReplicas = 2
+	if err := r.Update(context.Background(), dep); err != nil {
+		t.Fatal(err)
+	}
+	reconcileUntilStable(t, r, "tokens")
+
+	status := getModelServe(t, r, "tokens").Status
+	if status.PromptTokens != 120 || status.GeneratedTokens != 42 {
+		t.Fatalf("expected totals 120/42 across pods, got %d/%d", status.PromptTokens, status.GeneratedTokens)
+	}
+
+	// The next poll picks up new usage
+	counts["tokens-a"] = TokenCounts{Prompt: 150, Generated: 60}
+	reconcileUntilStable(t, r, "tokens")
+	if status := getModelServe(t, r, "tokens").Status; status.PromptTokens != 170 || status.GeneratedTokens != 62 {
+		t.Fatalf("expected totals to advance to 170/62, got %d/%d", status.PromptTokens, status.GeneratedTokens)
+	}
+}
+
+func TestParseTokenCounts(t *testing.T) {
+	body := `# HELP llamacpp:prompt_tokens_total Number of prompt tokens processed.
+# TYPE llamacpp:prompt_tokens_total counter
+llamacpp:prompt_tokens_total 1234
+llamacpp:tokens_predicted_total{slot="0"} 500
+llamacpp:tokens_predicted_total{slot="1"} 6.0
+llamacpp:kv_cache_usage_ratio 0.25
+`
+	got, err := parseTokenCounts([]byte(body))
+	if err != nil || got.Prompt != 1234 || got.Generated != 506 {
+		t.Fatalf("parseTokenCounts = %+v, %v; want 1234/506", got, err)
+	}
+
+	if _, err := parseTokenCounts([]byte("up 1\n")); err == nil {
+		t.Fatal("expected an error without token metrics")
+	}
+}
@@ -0,0 +1,163 @@
+package controller
+
+import (
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	modelv1alpha1 "github.com/example/model-operator/api/v1alpha1"
+)
+
+// warmupBackendName names the shared "model warming up" backend of a namespace
+const warmupBackendName = "model-warmup"
+
+// warmupNginxConfig answers every request with 503 and a Retry-After hint
+const warmupNginxConfig = `server {
+    listen 8080;
+    default_type application/json;
+    location / {
+        add_header Retry-After 30 always;
+        return 503 '{"error": "model is warming up, retry later"}';
+    }
+}
+`
+
+// warmingUp reports whether the model route should point at the warmup backend
+func warmingUp(m *modelv1alpha1.ModelServe, dep *appsv1.Deployment) bool {
+	return m.Spec.WarmupBackend && dep.Status.AvailableReplicas == 0
+}
+
+// ensureWarmupBackend creates the shared warmup backend of the namespace and
+// keeps existing objects in sync with it, e.g. a backend created by an older
+// operator. It is not owned by any ModelServe since all of them share it.
+func (r *ModelServeReconciler) ensureWarmupBackend(ctx context.Context, namespace string) error {
+	ls := map[string]string{"app": warmupBackendName}
+	replicas := int32(1)
+	runAsNonRoot := true
+	allowPrivilegeEscalation := false
+	// The nginx user of the unprivileged image
+	nginxUID := int64(101)
+
+	dep := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: warmupBackendName, Namespace: namespace, Labels: ls},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: ls},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: ls},
+				Spec: corev1.PodSpec{
+					SecurityContext: &corev1.PodSecurityContext{
+						RunAsNonRoot:   &runAsNonRoot,
+						RunAsUser:      &nginxUID,
+						RunAsGroup:     &nginxUID,
+						SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+					},
+					Containers: []corev1.Container{{
+						Name:  "nginx",
+						Image: "nginxinc/nginx-unprivileged:alpine",
+						Ports: []corev1.ContainerPort{{ContainerPort: 8080, Name: "http"}},
+						SecurityContext: &corev1.SecurityContext{
+							AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+							Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+						},
+						VolumeMounts: []corev1.VolumeMount{
+							{Name: "config", MountPath: "/etc/nginx/conf.d"},
+						},
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceMemory: resource.MustParse("16Mi"),
+								corev1.ResourceCPU:    resource.MustParse("10m"),
+							},
+							Limits: corev1.ResourceList{
+								corev1.ResourceMemory: resource.MustParse("32Mi"),
+								corev1.ResourceCPU:    resource.MustParse("50m"),
+							},
+						},
+					}},
+					Volumes: []corev1.Volume{{
+						Name: "config",
+						VolumeSource: corev1.VolumeSource{
+							ConfigMap: &corev1.ConfigMapVolumeSource{
+								LocalObjectReference: corev1.LocalObjectReference{Name: warmupBackendName},
+							},
+						},
+					}},
+				},
+			},
+		},
+	}
+	setTemplateHash(dep)
+
+	objs := []client.Object{
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: warmupBackendName, Namespace: namespace, Labels: ls},
+			Data:       map[string]string{"default.conf": warmupNginxConfig},
+		},
+		dep,
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: warmupBackendName, Namespace: namespace, Labels: ls},
+			Spec: corev1.ServiceSpec{
+				Selector: ls,
+				Ports: []corev1.ServicePort{{
+					Name:       "http",
+					Port:       80,
+					TargetPort: intstr.FromInt(8080),
+				}},
+				Type: corev1.ServiceTypeClusterIP,
+			},
+		},
+	}
+
+	for _, obj := range objs {
+		existing := obj.DeepCopyObject().(client.Object)
+		err := r.Get(ctx, types.NamespacedName{Name: obj.GetName(), Namespace: namespace}, existing)
+		if errors.IsNotFound(err) {
+			err = r.Create(ctx, obj)
+			if errors.IsAlreadyExists(err) {
+				continue
+			}
+		} else if err == nil && syncWarmupObject(existing, obj) {
+			err = r.Update(ctx, existing)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncWarmupObject copies the desired fields of the warmup backend onto found
+// and reports whether it changed. Fields defaulted by the API server are left
+// alone.
+func syncWarmupObject(found, desired client.Object) bool {
+	switch desired := desired.(type) {
+	case *corev1.ConfigMap:
+		found := found.(*corev1.ConfigMap)
+		if equality.Semantic.DeepEqual(found.Data, desired.Data) {
+			return false
+		}
+		found.Data = desired.Data
+	case *appsv1.Deployment:
+		found := found.(*appsv1.Deployment)
+		if found.Annotations[templateHashAnnotation] == desired.Annotations[templateHashAnnotation] {
+			return false
+		}
+		found.Spec.Template = desired.Spec.Template
+		mergeAnnotations(found, map[string]string{templateHashAnnotation: desired.Annotations[templateHashAnnotation]})
+	case *corev1.Service:
+		found := found.(*corev1.Service)
+		if servicePortsMatch(found.Spec.Ports, desired.Spec.Ports) {
+			return false
+		}
+		found.Spec.Ports = desired.Spec.Ports
+	}
+	return true
+}
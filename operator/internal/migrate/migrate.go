@@ -0,0 +1,51 @@
+// Package migrate converts stored ModelServes to newer API versions
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	modelv1alpha1 "github.com/example/model-operator/api/v1alpha1"
+	modelv1beta1 "github.com/example/model-operator/api/v1beta1"
+)
+
+// ToV1beta1 reads the v1alpha1 ModelServes of the namespace, or of all
+// namespaces when it is empty, and writes the equivalent v1beta1 objects to w
+// as YAML documents ready to apply. Server managed metadata and the status
+// are left out.
+func ToV1beta1(ctx context.Context, c client.Reader, namespace string, w io.Writer) error {
+	modelServes := &modelv1alpha1.ModelServeList{}
+	if err := c.List(ctx, modelServes, client.InNamespace(namespace)); err != nil {
+		return fmt.Errorf("failed to list ModelServes: %w", err)
+	}
+
+	for i := range modelServes.Items {
+		src := &modelServes.Items[i]
+		dst := &modelv1beta1.ModelServe{}
+		if err := dst.ConvertFrom(src); err != nil {
+			return fmt.Errorf("failed to convert ModelServe %s/%s: %w", src.Namespace, src.Name, err)
+		}
+		dst.TypeMeta = metav1.TypeMeta{APIVersion: modelv1beta1.GroupVersion.String(), Kind: "ModelServe"}
+		dst.ObjectMeta = metav1.ObjectMeta{
+			Name:        src.Name,
+			Namespace:   src.Namespace,
+			Labels:      src.Labels,
+			Annotations: src.Annotations,
+		}
+		dst.Status = modelv1beta1.ModelServeStatus{}
+
+		out, err := yaml.Marshal(dst)
+		if err != nil {
+			return fmt.Errorf("failed to encode ModelServe %s/%s: %w", src.Namespace, src.Name, err)
+		}
+		if _, err := fmt.Fprintf(w, "---\n%s", out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+
+	modelv1alpha1 "github.com/example/model-operator/api/v1alpha1"
+	modelv1beta1 "github.com/example/model-operator/api/v1beta1"
+)
+
+func TestToV1beta1WritesConvertedObjects(t *testing.T) {
+	s := runtime.NewScheme()
+	if err := modelv1alpha1.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	ms := &modelv1alpha1.ModelServe{
+		ObjectMeta: metav1.ObjectMeta{Name: "llama", Namespace: "models"},
+		Spec: modelv1alpha1.ModelServeSpec{
+			ModelName:   "llama.gguf",
+			ModelUUID:   "llama-uuid",
+			MinIOPath:   "models/llama.gguf",
+			MemoryLimit: 4096,
+		},
+		Status: modelv1alpha1.ModelServeStatus{Phase: "Running"},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(ms).Build()
+
+	var out bytes.Buffer
+	if err := ToV1beta1(context.Background(), c, "models", &out); err != nil {
+		t.Fatal(err)
+	}
+
+	docs := strings.Split(strings.TrimPrefix(out.String(), "---\n"), "---\n")
+	if len(docs) != 1 {
+		t.Fatalf("expected one document, got %d:\n%s", len(docs), out.String())
+	}
+	got := &modelv1beta1.ModelServe{}
+	if err := yaml.Unmarshal([]byte(docs[0]), got); err != nil {
+		t.Fatal(err)
+	}
+	if got.APIVersion != "model.example.com/v1beta1" || got.Kind != "ModelServe" {
+		t.Fatalf("expected a v1beta1 ModelServe, got %s %s", got.APIVersion, got.Kind)
+	}
+	if got.ResourceVersion != "" || got.Status.Phase != "" {
+		t.Fatalf("expected no server managed fields, got resourceVersion %q and phase %q", got.ResourceVersion, got.Status.Phase)
+	}
+	if len(got.Spec.Models) != 1 || got.Spec.Models[0].Name != "llama.gguf" {
+		t.Fatalf("expected the model in models, got %+v", got.Spec.Models)
+	}
+	if memory := got.Spec.Resources.Limits.Memory(); memory.String() != "4Gi" {
+		t.Fatalf("expected a 4Gi memory limit, got %s", memory.String())
+	}
+}
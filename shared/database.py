@@ -6,7 +6,7 @@ Provides database connection and model definitions for server and model tracking
 import os
 from datetime import datetime
 from typing import Optional
-from sqlalchemy import create_engine, Column, Integer, String, DateTime, Float, JSON, Text
+from sqlalchemy import create_engine, Column, Integer, String, DateTime, Float, JSON, Text, BigInteger
 from sqlalchemy.ext.declarative import declarative_base
 from sqlalchemy.orm import sessionmaker
 from sqlalchemy.sql import func
@@ -51,6 +51,10 @@ class ServerRecord(Base):
     memory_max_mb = Column(Integer, default=0)
     cpu_usage_percent = Column(Float, default=0.0)
     
+    # Token usage reported by the server metrics
+    prompt_tokens_total = Column(BigInteger, default=0)
+    generated_tokens_total = Column(BigInteger, default=0)
+    
     # Timestamps
     created_at = Column(DateTime(timezone=True), server_default=func.now())
     started_at = Column(DateTime(timezone=True))